@@ -0,0 +1,291 @@
+package database
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetDriver gets which of the Driver* constants a database is
+func GetDriver(d Database) string {
+	switch d.(type) {
+	case *MySQL:
+		return DriverMySQL
+	case *Postgres:
+		return DriverPostgres
+	}
+
+	return DriverSQLite
+}
+
+// QuoteIdentifierForDriver quotes a column name the way the given driver does, for writing SQL meant for another database
+func QuoteIdentifierForDriver(driver, name string) string {
+	if driver == DriverMySQL {
+		return quoteIdentifier(name, "`")
+	}
+
+	return quoteIdentifier(name, `"`)
+}
+
+// QuoteTableNameForDriver quotes a possibly schema qualified table name the way the given driver does
+func QuoteTableNameForDriver(driver, name string) string {
+	if driver == DriverMySQL {
+		return quoteQualifiedName(name, "`")
+	}
+
+	return quoteQualifiedName(name, `"`)
+}
+
+// FormatLiteral writes a value as a SQL literal for the given driver. kind is the kind of the column the value goes in,
+// which decides how values sqlite keeps in other types (booleans as integers) are written
+func FormatLiteral(driver string, kind ColumnKind, val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if driver == DriverSQLite {
+			if v {
+				return "1"
+			}
+			return "0"
+		}
+		return strings.ToUpper(strconv.FormatBool(v))
+	case int64:
+		if kind == KindBool && driver != DriverSQLite {
+			return strings.ToUpper(strconv.FormatBool(v != 0))
+		}
+		return strconv.FormatInt(v, 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "NULL" // no database takes these as literals the same way
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case float32:
+		return FormatLiteral(driver, kind, float64(v))
+	case []byte:
+		if kind == KindText {
+			return FormatLiteral(driver, kind, string(v))
+		}
+		if driver == DriverPostgres {
+			return `'\x` + hex.EncodeToString(v) + `'::bytea`
+		}
+		return "X'" + hex.EncodeToString(v) + "'"
+	case time.Time:
+		layout := "2006-01-02 15:04:05.999999999-07:00"
+		if driver == DriverMySQL { // mysql only has microseconds, and older versions don't take an offset
+			layout = "2006-01-02 15:04:05.999999"
+		}
+		return FormatLiteral(driver, kind, v.Format(layout))
+	case string:
+		if driver == DriverMySQL { // backslashes are escapes in mysql strings unless NO_BACKSLASH_ESCAPES is set
+			v = strings.NewReplacer(`\`, `\\`, "\x00", `\0`).Replace(v)
+		}
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	}
+
+	return FormatLiteral(driver, kind, fmt.Sprintf("%v", val))
+}
+
+// GetTypeForDriver picks a column type for driver that holds what a column declared as declared in another database does
+func GetTypeForDriver(driver, declared string) string {
+	lower := strings.ToLower(declared)
+	switch driver {
+	case DriverSQLite:
+		return declared // sqlite takes any type name, and goes by affinity
+	case DriverMySQL:
+		switch GetColumnKind(declared) {
+		case KindInteger:
+			return "BIGINT"
+		case KindReal:
+			return "DOUBLE"
+		case KindNumeric:
+			if strings.Contains(lower, "(") {
+				return declared
+			}
+			return "DECIMAL(65,30)"
+		case KindBool:
+			return "BOOLEAN"
+		case KindTime:
+			if lower == "date" || lower == "time" {
+				return strings.ToUpper(lower)
+			}
+			return "DATETIME(6)"
+		case KindBlob:
+			return "LONGBLOB"
+		}
+		return "LONGTEXT"
+	case DriverPostgres:
+		switch GetColumnKind(declared) {
+		case KindInteger:
+			return "BIGINT"
+		case KindReal:
+			return "DOUBLE PRECISION"
+		case KindNumeric:
+			return "NUMERIC"
+		case KindBool:
+			return "BOOLEAN"
+		case KindTime:
+			if lower == "date" || strings.HasPrefix(lower, "time") && !strings.HasPrefix(lower, "timestamp") {
+				return strings.ToUpper(lower)
+			}
+			return "TIMESTAMP"
+		case KindBlob:
+			return "BYTEA"
+		}
+		return "TEXT"
+	}
+
+	return declared
+}
+
+// GenerateCreateTable writes a CREATE TABLE statement for driver from just the columns of a table. Used when dumping
+// to another database than the table came from, whose CREATE statements wouldn't work there. indexed are the columns
+// in an index, mysql can't index text columns without a length
+func GenerateCreateTable(driver, table string, columns []ColumnInfo, indexed map[string]bool) string {
+	var (
+		lines []string
+		keys  = make([]string, len(columns))
+	)
+	for _, c := range columns {
+		line := "  " + QuoteIdentifierForDriver(driver, c.Name)
+		t := GetTypeForDriver(driver, c.Type)
+		if t == "LONGTEXT" && (c.PrimaryKey > 0 || indexed[c.Name]) {
+			t = "VARCHAR(255)"
+		}
+		if t != "" {
+			line += " " + t
+		}
+		if c.NotNull {
+			line += " NOT NULL"
+		}
+		lines = append(lines, line)
+		if c.PrimaryKey > 0 && c.PrimaryKey <= len(keys) {
+			keys[c.PrimaryKey-1] = QuoteIdentifierForDriver(driver, c.Name)
+		}
+	}
+
+	var primary []string
+	for _, k := range keys {
+		if k != "" {
+			primary = append(primary, k)
+		}
+	}
+	if len(primary) > 0 {
+		lines = append(lines, "  PRIMARY KEY ("+strings.Join(primary, ", ")+")")
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (\n%s\n)", QuoteTableNameForDriver(driver, table), strings.Join(lines, ",\n"))
+}
+
+// GenerateCreateIndex writes a CREATE INDEX statement for driver from the columns of an index. Indexes on expressions
+// can't be written this way and give an empty string
+func GenerateCreateIndex(driver, table string, index IndexInfo) string {
+	var columns []string
+	for _, c := range index.Columns {
+		if c == "(expression)" {
+			return ""
+		}
+		columns = append(columns, QuoteIdentifierForDriver(driver, c))
+	}
+
+	name := strings.TrimPrefix(index.Name, "sqlite_") // reserved in sqlite, these back UNIQUE constraints
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique, QuoteIdentifierForDriver(driver, name),
+		QuoteTableNameForDriver(driver, table), strings.Join(columns, ", "))
+}
+
+// GetDumpName is the name a table gets in a dump for driver. Schemas only mean something when dumping postgres to postgres,
+// and attached sqlite databases aren't there when the dump is loaded
+func GetDumpName(d Database, driver, tableName string) string {
+	if GetDriver(d) == DriverPostgres && driver == DriverPostgres {
+		return tableName
+	}
+	if s, ok := d.(*SQLite); ok {
+		_, table := s.splitTableName(tableName)
+		return table
+	}
+
+	_, table := splitQualifiedName(tableName)
+	return table
+}
+
+// GetDumpSchema is the schema a dump for driver has to create before the table can be, empty when there's none. Only
+// postgres dumped to postgres keeps schemas, and public is always there
+func GetDumpSchema(d Database, driver, tableName string) string {
+	if GetDriver(d) != DriverPostgres || driver != DriverPostgres {
+		return ""
+	}
+	if schema, _ := splitQualifiedName(tableName); schema != "public" {
+		return schema
+	}
+
+	return ""
+}
+
+// GenerateCreateSchema writes a CREATE SCHEMA statement for driver, which does nothing if the schema is already there
+func GenerateCreateSchema(driver, schema string) string {
+	return fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", QuoteIdentifierForDriver(driver, schema))
+}
+
+// GetCreateStatements gets the statements that create a table in a dump for driver: the table, then the ones that go
+// after its rows, its indexes and for postgres restarting the numbering of identity columns. The database's own
+// statements are used when dumping to the same kind of database, otherwise they're made up from the schema
+func GetCreateStatements(d Database, driver, tableName string) (string, []string, error) {
+	schema, err := d.GetSchema(tableName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	name := GetDumpName(d, driver, tableName)
+	var indexes []string
+	if GetDriver(d) != driver {
+		indexed := make(map[string]bool)
+		for _, index := range schema.Indexes {
+			if index.Primary {
+				continue
+			}
+			if statement := GenerateCreateIndex(driver, name, index); statement != "" {
+				indexes = append(indexes, statement)
+				for _, c := range index.Columns {
+					indexed[c] = true
+				}
+			}
+		}
+		return GenerateCreateTable(driver, name, schema.Columns, indexed), indexes, nil
+	}
+
+	create := schema.Create
+	switch driver {
+	case DriverSQLite: // the original statements, implicit indexes come with the table. Attached tables are stored unqualified
+		for _, index := range schema.Indexes {
+			if index.SQL != "" {
+				indexes = append(indexes, index.SQL)
+			}
+		}
+	case DriverPostgres: // constraints are in the generated CREATE TABLE and already make their indexes
+		for _, index := range schema.Indexes {
+			if !index.Primary && index.SQL != "" {
+				indexes = append(indexes, strings.Replace(index.SQL, "INDEX ", "INDEX IF NOT EXISTS ", 1))
+			}
+		}
+		before, after := GenerateLoadIdentity(name, schema.Identity)
+		create = strings.TrimSuffix(create, ";")
+		for _, statement := range before {
+			create += ";\n" + statement
+		}
+		indexes = append(indexes, after...)
+	} // SHOW CREATE TABLE has every index of a mysql table in it
+
+	return create, indexes, nil
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetDumpSchema(t *testing.T) {
+	tests := []struct {
+		db     Database
+		driver string
+		table  string
+		want   string
+	}{
+		{&Postgres{}, DriverPostgres, "sales.orders", "sales"},
+		{&Postgres{}, DriverPostgres, "public.orders", ""},
+		{&Postgres{}, DriverPostgres, `we"ird.t`, `we"ird`},
+		{&Postgres{}, DriverSQLite, "sales.orders", ""},
+		{&SQLite{}, DriverPostgres, "orders", ""},
+		{&MySQL{}, DriverMySQL, "shop.orders", ""},
+	}
+	for _, test := range tests {
+		if got := GetDumpSchema(test.db, test.driver, test.table); got != test.want {
+			t.Errorf("GetDumpSchema(%T, %s, %q) = %q, want %q", test.db, test.driver, test.table, got, test.want)
+		}
+	}
+
+	if got, want := GenerateCreateSchema(DriverPostgres, `we"ird`), `CREATE SCHEMA IF NOT EXISTS "we""ird"`; got != want {
+		t.Errorf("GenerateCreateSchema = %s, want %s", got, want)
+	}
+}
+
+func TestGenerateLoadIdentity(t *testing.T) {
+	before, after := GenerateLoadIdentity("sales.order's", []IdentityColumn{{Name: "id"}, {Name: "Line", Always: true}})
+	wantBefore := []string{`ALTER TABLE "sales"."order's" ALTER COLUMN "Line" SET GENERATED BY DEFAULT`}
+	wantAfter := []string{
+		`SELECT setval(pg_get_serial_sequence('"sales"."order''s"', 'id'), COALESCE(MAX("id"), 0) + 1, false) FROM "sales"."order's"`,
+		`SELECT setval(pg_get_serial_sequence('"sales"."order''s"', 'Line'), COALESCE(MAX("Line"), 0) + 1, false) FROM "sales"."order's"`,
+		`ALTER TABLE "sales"."order's" ALTER COLUMN "Line" SET GENERATED ALWAYS`,
+	}
+	if !reflect.DeepEqual(before, wantBefore) {
+		t.Errorf("before the rows = %q, want %q", before, wantBefore)
+	}
+	if !reflect.DeepEqual(after, wantAfter) {
+		t.Errorf("after the rows = %q, want %q", after, wantAfter)
+	}
+
+	tests := []struct {
+		def, generated, want string
+	}{
+		{"", "", `"id" integer NOT NULL`},
+		{"42", "", `"id" integer NOT NULL DEFAULT 42`},
+		{"nextval('t_id_seq'::regclass)", "default", `"id" integer NOT NULL GENERATED BY DEFAULT AS IDENTITY`},
+		{"", "always", `"id" integer NOT NULL GENERATED ALWAYS AS IDENTITY`},
+	}
+	for _, test := range tests {
+		if got := testPostgres.generateColumn("id", "integer", true, test.def, test.generated); got != test.want {
+			t.Errorf("generateColumn(%q, %q) = %s, want %s", test.def, test.generated, got, test.want)
+		}
+	}
+}
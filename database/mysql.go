@@ -0,0 +1,152 @@
+package database
+
+import (
+	"database/sql"
+)
+
+// MySQL talks to a server instead of a file, so the DSN takes the place of the file name
+// (e.g. user:password@tcp(localhost:3306)/dbname)
+type MySQL struct {
+	DSN      string
+	Database *sql.DB
+}
+
+func (db *MySQL) Update(q *Update) (int64, error) {
+	return executeUpdate(db, q)
+}
+
+func (db *MySQL) Delete(q *Delete) (int64, error) {
+	return executeDelete(db, q)
+}
+
+func (db *MySQL) GetFileName() string {
+	return db.DSN
+}
+
+func (db *MySQL) GetDatabaseReference() *sql.DB {
+	return db.Database
+}
+
+func (db *MySQL) CloseDatabaseReference() {
+	CloseDatabaseForFile(db.DSN)
+	db.Database = nil
+}
+
+func (db *MySQL) SetDatabaseReference(dsn string) {
+	database := GetDatabaseForFile(dsn)
+	db.DSN = dsn
+	db.Database = database
+}
+
+func (db MySQL) GetPlaceholderForDatabaseType(position int) string {
+	return "?"
+}
+
+func (db MySQL) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, "`")
+}
+
+func (db MySQL) QuoteTableName(name string) string {
+	return quoteIdentifier(name, "`")
+}
+
+func (db MySQL) GetTableNamesQuery() string {
+	val := "SELECT table_name, CASE WHEN table_type LIKE '%VIEW' THEN '" + TableKindView + "' ELSE '" + TableKindTable + "' END FROM "
+	val += "information_schema.tables"
+	val += " WHERE table_schema = DATABASE()"
+	val += " ORDER BY table_name"
+
+	return val
+}
+
+func (db *MySQL) GenerateQuery(u *Update) (string, []string) {
+	return generateUpdateQuery(db, u)
+}
+
+func (db *MySQL) GetRowIdentity(tableName string) (*RowIdentity, error) {
+	keys, err := getPrimaryKeyColumns(db, "SELECT column_name FROM information_schema.key_column_usage"+
+		" WHERE table_schema = DATABASE() AND table_name = ? AND constraint_name = 'PRIMARY'"+
+		" ORDER BY ordinal_position", tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, noRowIdentityError(tableName)
+	}
+
+	return &RowIdentity{
+		Columns: keys,
+	}, nil
+}
+
+func (db *MySQL) GetColumns(tableName string) ([]ColumnInfo, error) {
+	return getColumns(db, tableName, "SELECT column_name, column_type, is_nullable = 'NO', column_default"+
+		" FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ?"+
+		" ORDER BY ordinal_position", tableName)
+}
+
+func (db *MySQL) Insert(q *Insert) (int64, error) {
+	return executeInsert(db, q, "() VALUES ()")
+}
+
+func (db *MySQL) GetForeignKeys(tableName string) ([]ForeignKey, error) {
+	rows, err := queryStrings(db, "SELECT k.constraint_name, k.column_name, k.referenced_table_name, k.referenced_column_name,"+
+		" r.update_rule, r.delete_rule"+
+		" FROM information_schema.key_column_usage k JOIN information_schema.referential_constraints r"+
+		" ON r.constraint_schema = k.constraint_schema AND r.constraint_name = k.constraint_name AND r.table_name = k.table_name"+
+		" WHERE k.table_schema = DATABASE() AND k.table_name = ? AND k.referenced_table_name IS NOT NULL"+
+		" ORDER BY k.constraint_name, k.ordinal_position", tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	return groupForeignKeys(rows), nil
+}
+
+func (db *MySQL) GetSchema(tableName string) (*TableSchema, error) {
+	schema := &TableSchema{
+		Name: tableName,
+	}
+
+	var err error
+	if schema.Columns, err = db.GetColumns(tableName); err != nil {
+		return nil, err
+	}
+
+	if schema.ForeignKeys, err = db.GetForeignKeys(tableName); err != nil {
+		return nil, err
+	}
+
+	rows, err := queryStrings(db, "SELECT index_name, coalesce(column_name, '(expression)'), non_unique = 0, index_name = 'PRIMARY', NULL"+
+		" FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ?"+
+		" ORDER BY index_name, seq_in_index", tableName)
+	if err != nil {
+		return nil, err
+	}
+	schema.Indexes = groupIndexes(rows)
+
+	rows, err = queryStrings(db, "SELECT trigger_name, concat('CREATE TRIGGER ', trigger_name, ' ', action_timing, ' ',"+
+		" event_manipulation, ' ON ', event_object_table, ' FOR EACH ROW ', action_statement)"+
+		" FROM information_schema.triggers WHERE event_object_schema = DATABASE() AND event_object_table = ?"+
+		" ORDER BY trigger_name", tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		schema.Triggers = append(schema.Triggers, TriggerInfo{
+			Name: r[0],
+			SQL:  r[1],
+		})
+	}
+
+	rows, err = queryStrings(db, "SHOW CREATE TABLE "+db.QuoteTableName(tableName)) // table name, then the statement
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 && len(rows[0]) > 1 {
+		schema.Create = rows[0][1]
+	}
+
+	return schema, nil
+}
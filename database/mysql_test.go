@@ -0,0 +1,235 @@
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDriver stands in for a mysql server for one test. It remembers every statement it's given, and answers queries
+// with the rows set up for the first query that starts the same way
+type fakeDriver struct {
+	mu         sync.Mutex
+	statements []fakeStatement
+	results    map[string][][]driver.Value // by query prefix, the first value of each row names the columns
+	commits    int
+	rollbacks  int
+}
+
+type fakeStatement struct {
+	query string
+	args  []driver.Value
+}
+
+func (d *fakeDriver) record(query string, args []driver.Value) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statements = append(d.statements, fakeStatement{query, args})
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{c.d, query}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{c.d}, nil }
+
+type fakeTx struct{ d *fakeDriver }
+
+func (tx fakeTx) Commit() error {
+	tx.d.mu.Lock()
+	defer tx.d.mu.Unlock()
+	tx.d.commits++
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.d.mu.Lock()
+	defer tx.d.mu.Unlock()
+	tx.d.rollbacks++
+	return nil
+}
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.record(s.query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.record(s.query, args)
+	for prefix, rows := range s.d.results {
+		if strings.HasPrefix(s.query, prefix) {
+			return &fakeRows{columns: rows[0], rows: rows[1:]}, nil
+		}
+	}
+
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct {
+	columns []driver.Value
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	columns := make([]string, len(r.columns))
+	for i, c := range r.columns {
+		columns[i] = c.(string)
+	}
+	return columns
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+var registerFakeMySQL sync.Once
+
+// newFakeMySQL gets a MySQL whose connection goes to a fresh fakeDriver
+func newFakeMySQL(t *testing.T, results map[string][][]driver.Value) (*MySQL, *fakeDriver) {
+	fake := &fakeDriver{results: results}
+	registerFakeMySQL.Do(func() {
+		sql.Register("fakemysql", &fakeDrivers{})
+	})
+	fakeDriversByName.Store(t.Name(), fake)
+	db, err := sql.Open("fakemysql", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return &MySQL{DSN: t.Name(), Database: db}, fake
+}
+
+// fakeDrivers hands every test its own fakeDriver, by DSN, since drivers can only be registered once
+type fakeDrivers struct{}
+
+var fakeDriversByName sync.Map
+
+func (fakeDrivers) Open(name string) (driver.Conn, error) {
+	d, _ := fakeDriversByName.Load(name)
+	return &fakeConn{d.(*fakeDriver)}, nil
+}
+
+func TestMySQLGetTableNamesQuery(t *testing.T) {
+	db, fake := newFakeMySQL(t, map[string][][]driver.Value{
+		"SELECT table_name": {{"table_name", "kind"}, {"order", TableKindTable}, {"order totals", TableKindView}},
+	})
+
+	query := db.GetTableNamesQuery()
+	if !strings.Contains(query, "table_schema = DATABASE()") {
+		t.Errorf("table names aren't limited to the current database: %s", query)
+	}
+	rows, err := db.GetDatabaseReference().Query(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	got := make(map[string]string)
+	for rows.Next() {
+		var name, kind string
+		if err := rows.Scan(&name, &kind); err != nil {
+			t.Fatal(err)
+		}
+		got[name] = kind
+	}
+	if want := map[string]string{"order": TableKindTable, "order totals": TableKindView}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tables = %v, want %v", got, want)
+	}
+	if len(fake.statements) != 1 || fake.statements[0].query != query {
+		t.Errorf("statements = %v, want just %s", fake.statements, query)
+	}
+}
+
+func TestMySQLUpdate(t *testing.T) {
+	db, fake := newFakeMySQL(t, nil)
+
+	u := &Update{Column: "first name", Update: "Bob", TableName: "order"}
+	u.SetValues(map[string]interface{}{"id": int64(3), "key`part": "a"})
+	query, order := db.GenerateQuery(u)
+	want := "UPDATE `order` SET `first name`=? WHERE `id`=? AND `key``part`=?;"
+	if query != want {
+		t.Errorf("GenerateQuery = %s, want %s", query, want)
+	}
+	if wantOrder := []string{"first name", "id", "key`part"}; !reflect.DeepEqual(order, wantOrder) {
+		t.Errorf("GenerateQuery value order = %v, want %v", order, wantOrder)
+	}
+
+	affected, err := db.Update(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if affected != 1 {
+		t.Errorf("Update affected %d rows, want 1", affected)
+	}
+	if len(fake.statements) != 1 {
+		t.Fatalf("statements = %v, want one UPDATE", fake.statements)
+	}
+	if got := fake.statements[0]; got.query != want || !reflect.DeepEqual(got.args, []driver.Value{"Bob", int64(3), "a"}) {
+		t.Errorf("ran %s with %v, want %s with [Bob 3 a]", got.query, got.args, want)
+	}
+
+	u.Update = nil // NULL is bound, not written into the query
+	if _, err = db.Update(u); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.statements[1]; got.query != want || got.args[0] != nil {
+		t.Errorf("ran %s with %v, want %s with a nil first argument", got.query, got.args, want)
+	}
+}
+
+func TestMySQLGetRowIdentity(t *testing.T) {
+	db, fake := newFakeMySQL(t, map[string][][]driver.Value{
+		"SELECT column_name": {{"column_name"}, {"order_id"}, {"line"}},
+	})
+
+	identity, err := db.GetRowIdentity("order`s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(identity.Columns, []string{"order_id", "line"}) || identity.Hidden {
+		t.Errorf("identity = %+v, want the primary key columns order_id, line", identity)
+	}
+	if len(fake.statements) != 1 || !reflect.DeepEqual(fake.statements[0].args, []driver.Value{"order`s"}) ||
+		strings.Contains(fake.statements[0].query, "order`s") {
+		t.Errorf("the table name should be bound as an argument: %v", fake.statements)
+	}
+}
+
+func TestDeleteRows(t *testing.T) {
+	db, fake := newFakeMySQL(t, nil)
+
+	deleted, err := DeleteRows(db, "order", []map[string]interface{}{{"id": int64(1)}, {"id": int64(2)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 2 || len(fake.statements) != 2 || fake.commits != 1 {
+		t.Errorf("deleted %d row(s) with %v and %d commit(s), want 2 rows, 2 statements and 1 commit", deleted, fake.statements, fake.commits)
+	}
+
+	deleted, err = DeleteRows(db, "order", []map[string]interface{}{{"id": int64(3)}, {}})
+	if err == nil || deleted != 0 {
+		t.Errorf("deleting a row without an identity = %d, %v, want an error", deleted, err)
+	}
+	if fake.commits != 1 || fake.rollbacks != 1 {
+		t.Errorf("%d commit(s) and %d rollback(s), want the failed delete rolled back", fake.commits, fake.rollbacks)
+	}
+}
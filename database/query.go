@@ -2,9 +2,34 @@ package database
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 )
 
+const (
+	DriverSQLite   = "sqlite"
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+)
+
+// what GetTableNamesQuery says something is
+const (
+	TableKindTable   = "table"
+	TableKindView    = "view"
+	TableKindVirtual = "virtual" // sqlite virtual tables, like FTS
+	TableKindShadow  = "shadow"  // tables a virtual table keeps its data in
+)
+
+var (
+	ErrNoRowIdentity = errors.New("has no primary key or rowid, so its rows can't be edited")
+	ErrReadOnly      = errors.New("is a view, so its rows can't be edited")
+	ErrAttached      = errors.New("is in an attached database, which is opened read-only")
+)
+
 var (
 	DBMutex      sync.Mutex
 	Databases    map[string]*sql.DB
@@ -24,18 +49,41 @@ type Query interface {
 }
 
 type Database interface {
-	Update(q *Update)
+	Update(q *Update) (int64, error) // returns the number of rows affected
+	Insert(q *Insert) (int64, error) // returns the number of rows affected, and sets q.Key
+	Delete(q *Delete) (int64, error) // returns the number of rows affected
 	GenerateQuery(u *Update) (string, []string)
-	GetPlaceholderForDatabaseType() string
+	GetPlaceholderForDatabaseType(position int) string // position is 1-based
+	QuoteIdentifier(name string) string                // for column names
+	QuoteTableName(name string) string                 // for table names, which may be schema qualified
 	GetFileName() string
-	GetTableNamesQuery() string
+	GetTableNamesQuery() string // selects the name and TableKind of everything that can be viewed
+	GetRowIdentity(tableName string) (*RowIdentity, error)
+	GetColumns(tableName string) ([]ColumnInfo, error)
+	GetForeignKeys(tableName string) ([]ForeignKey, error)
+	GetSchema(tableName string) (*TableSchema, error)
 	GetDatabaseReference() *sql.DB
 	CloseDatabaseReference()
 	SetDatabaseReference(dbPath string)
 }
 
+// RowIdentity describes which columns address exactly one row of a table
+type RowIdentity struct {
+	Columns []string // primary key columns, or a pseudo column like rowid
+	Hidden  bool     // not part of select *, so it has to be selected explicitly
+}
+
+// ColumnInfo is what the schema declares about a column
+type ColumnInfo struct {
+	Name       string
+	Type       string         // declared type, as written in the schema
+	NotNull    bool           // NOT NULL constraint
+	Default    sql.NullString // default value expression, as written in the schema
+	PrimaryKey int            // 1-based position within the primary key, 0 if not part of it
+}
+
 type Update struct {
-	v         map[string]interface{} // these are anchors to ensure the right row/col gets updated
+	v         map[string]interface{} // these are anchors (row identity) to ensure the right row/col gets updated
 	Column    string                 // this is the header
 	Update    interface{}            // this is the new cell value
 	TableName string
@@ -49,6 +97,34 @@ func (u *Update) SetValues(v map[string]interface{}) {
 	u.v = v
 }
 
+type Insert struct {
+	v         map[string]interface{} // values of the new row by column, missing columns get their default
+	TableName string
+	Identity  *RowIdentity           // which columns to report back in Key, can be nil
+	Key       map[string]interface{} // row identity values of the inserted row, if they could be determined
+}
+
+func (i *Insert) GetValues() map[string]interface{} {
+	return i.v
+}
+
+func (i *Insert) SetValues(v map[string]interface{}) {
+	i.v = v
+}
+
+type Delete struct {
+	v         map[string]interface{} // row identity of the row to delete
+	TableName string
+}
+
+func (d *Delete) GetValues() map[string]interface{} {
+	return d.v
+}
+
+func (d *Delete) SetValues(v map[string]interface{}) {
+	d.v = v
+}
+
 // GetDatabaseForFile does what you think it does
 func GetDatabaseForFile(database string) *sql.DB {
 	DBMutex.Lock()
@@ -60,17 +136,380 @@ func GetDatabaseForFile(database string) *sql.DB {
 	if err != nil {
 		panic(err)
 	}
+	if DriverString == DriverSQLite {
+		// every connection to :memory: is a brand new database, and sqlite only has one writer anyway
+		db.SetMaxOpenConns(1)
+	}
 	Databases[database] = db
 	return db
 }
 
-func ProcessSqlQueryForDatabaseType(q Query, rowData map[string]interface{}, schemaName, columnName string, db *Database) {
+// CloseDatabaseForFile closes the pool for database and forgets about it, so the next
+// GetDatabaseForFile call opens a fresh one instead of handing back a closed pool
+func CloseDatabaseForFile(database string) {
+	DBMutex.Lock()
+	defer DBMutex.Unlock()
+	if db, ok := Databases[database]; ok {
+		db.Close()
+		delete(Databases, database)
+	}
+}
+
+// GetDatabaseImplementation wraps a connection in the Database that matches the connection's driver
+func GetDatabaseImplementation(info *ConnectionInfo, db *sql.DB) Database {
+	switch info.Driver {
+	case DriverMySQL:
+		return &MySQL{
+			DSN:      info.DSN,
+			Database: db,
+		}
+	case DriverPostgres:
+		return &Postgres{
+			DSN:      info.DSN,
+			Database: db,
+		}
+	default:
+		name, options := info.Path, info.Options
+		if name == "" { // in-memory, the options are already part of the DSN
+			name, options = info.DSN, ""
+		}
+		return &SQLite{
+			FileName: name,
+			Options:  options,
+			Database: db,
+		}
+	}
+}
+
+// ConvertScannedValue turns raw driver values into types the viewer knows how to render.
+// Some drivers (MySQL, Postgres) hand back []byte for anything sent over the text protocol.
+func ConvertScannedValue(val interface{}, column *sql.ColumnType) interface{} {
+	b, ok := val.([]byte)
+	if !ok || column == nil {
+		return val
+	}
+
+	typeName := strings.ToUpper(column.DatabaseTypeName())
+	switch {
+	case strings.Contains(typeName, "BLOB") || strings.Contains(typeName, "BINARY") || typeName == "BYTEA":
+		return b
+	case strings.Contains(typeName, "INT"):
+		if i, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+			return i
+		}
+	case typeName == "FLOAT" || typeName == "DOUBLE" || typeName == "REAL":
+		if f, err := strconv.ParseFloat(string(b), 64); err == nil {
+			return f
+		}
+	}
+
+	return string(b)
+}
+
+func ProcessSqlQueryForDatabaseType(q Query, rowData map[string]interface{}, schemaName, columnName string, db *Database) (int64, error) {
 	switch conv := q.(type) {
 	case *Update:
 		conv.SetValues(rowData)
 		conv.TableName = schemaName
 		conv.Column = columnName
-		(*db).Update(conv)
-		break
+		return (*db).Update(conv)
+	case *Insert:
+		conv.SetValues(rowData)
+		conv.TableName = schemaName
+		return (*db).Insert(conv)
+	case *Delete:
+		conv.SetValues(rowData)
+		conv.TableName = schemaName
+		return (*db).Delete(conv)
+	}
+
+	return 0, fmt.Errorf("unsupported query type %T", q)
+}
+
+// executeUpdate runs an Update generated by db inside of a transaction
+func executeUpdate(db Database, q *Update) (int64, error) {
+	protoQuery, columnOrder := db.GenerateQuery(q)
+	values := make([]interface{}, len(columnOrder))
+	updateValues := q.GetValues()
+	for i, v := range columnOrder {
+		if i == 0 {
+			values[i] = q.Update // nil binds as an actual NULL
+		} else {
+			values[i] = updateValues[v]
+		}
+	}
+
+	return executeStatement(db, protoQuery, values)
+}
+
+// executeDelete runs a DELETE anchored on the row identity in q inside of a transaction
+func executeDelete(db Database, q *Delete) (int64, error) {
+	if len(q.GetValues()) == 0 { // never delete without a WHERE clause
+		return 0, noRowIdentityError(q.TableName)
+	}
+	protoQuery, values := generateDeleteQuery(db, q)
+
+	return executeStatement(db, protoQuery, values)
+}
+
+// DeleteRows deletes the rows with the given identities from a table in one transaction, so on the first error
+// none of them are deleted
+func DeleteRows(db Database, tableName string, keys []map[string]interface{}) (int64, error) {
+	tx, err := db.GetDatabaseReference().Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, key := range keys {
+		if len(key) == 0 { // never delete without a WHERE clause
+			tx.Rollback()
+			return 0, noRowIdentityError(tableName)
+		}
+		q := &Delete{TableName: tableName}
+		q.SetValues(key)
+		protoQuery, values := generateDeleteQuery(db, q)
+		result, err := tx.Exec(protoQuery, values...)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		deleted += affected
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// generateDeleteQuery builds a DELETE statement anchored on every value in q
+func generateDeleteQuery(db Database, q *Delete) (string, []interface{}) {
+	var (
+		where  []string
+		values []interface{}
+		keys   []string
+	)
+
+	for k := range q.GetValues() {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys) // maps aren't deterministic
+
+	for i, k := range keys {
+		where = append(where, fmt.Sprintf("%s=%s", db.QuoteIdentifier(k), db.GetPlaceholderForDatabaseType(i+1)))
+		values = append(values, q.GetValues()[k])
+	}
+
+	return fmt.Sprintf("DELETE FROM %s WHERE %s;", db.QuoteTableName(q.TableName), strings.Join(where, " AND ")), values
+}
+
+// executeStatement runs a single statement inside of a transaction and reports the rows it affected
+func executeStatement(db Database, protoQuery string, values []interface{}) (int64, error) {
+	tx, err := db.GetDatabaseReference().Begin()
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := tx.Prepare(protoQuery)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	defer stmt.Close()
+	result, err := stmt.Exec(values...)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, nil
+}
+
+// generateInsertQuery builds an INSERT statement for every value in q. emptyValues is the dialect's
+// way of inserting a row made up entirely of defaults.
+func generateInsertQuery(db Database, q *Insert, emptyValues string) (string, []interface{}) {
+	var (
+		columns      []string
+		placeholders []string
+		values       []interface{}
+	)
+
+	for k := range q.GetValues() {
+		columns = append(columns, k)
+	}
+	sort.Strings(columns) // maps aren't deterministic
+
+	for i, k := range columns {
+		placeholders = append(placeholders, db.GetPlaceholderForDatabaseType(i+1))
+		values = append(values, q.GetValues()[k])
+		columns[i] = db.QuoteIdentifier(k)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s ", db.QuoteTableName(q.TableName))
+	if len(columns) == 0 {
+		return query + emptyValues, values
+	}
+
+	return query + fmt.Sprintf("(%s) VALUES (%s)",
+		strings.Join(columns, ", "),
+		strings.Join(placeholders, ", ")), values
+}
+
+// executeInsert runs an Insert generated by db inside of a transaction. The key of the new row comes
+// from the inserted values, or from the last insert id when that is the only key column missing.
+func executeInsert(db Database, q *Insert, emptyValues string) (int64, error) {
+	protoQuery, values := generateInsertQuery(db, q, emptyValues)
+	tx, err := db.GetDatabaseReference().Begin()
+	if err != nil {
+		return 0, err
+	}
+	result, err := tx.Exec(protoQuery, values...)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	if q.Identity != nil {
+		key := make(map[string]interface{})
+		var missing []string
+		for _, k := range q.Identity.Columns {
+			if v, ok := q.GetValues()[k]; ok && v != nil {
+				key[k] = v
+			} else {
+				missing = append(missing, k)
+			}
+		}
+		if len(missing) == 1 {
+			if id, err := result.LastInsertId(); err == nil {
+				key[missing[0]] = id
+				missing = nil
+			}
+		}
+		if len(missing) == 0 {
+			q.Key = key
+		}
+	}
+
+	return affected, nil
+}
+
+// generateUpdateQuery builds an UPDATE statement anchored on every value in u
+func generateUpdateQuery(db Database, u *Update) (string, []string) {
+	var (
+		query         string
+		querySkeleton string
+		valueOrder    []string
+	)
+
+	querySkeleton = fmt.Sprintf("UPDATE %s"+
+		" SET %s=%s ", db.QuoteTableName(u.TableName), db.QuoteIdentifier(u.Column), db.GetPlaceholderForDatabaseType(1))
+	valueOrder = append(valueOrder, u.Column)
+
+	whereBuilder := strings.Builder{}
+	whereBuilder.WriteString(" WHERE ")
+	var keys []string
+	for k := range u.GetValues() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys) // maps aren't deterministic
+	uLen := len(keys)
+	i := 0
+	for _, k := range keys {
+		assertion := fmt.Sprintf("%s=%s ", db.QuoteIdentifier(k), db.GetPlaceholderForDatabaseType(len(valueOrder)+1))
+		valueOrder = append(valueOrder, k)
+		whereBuilder.WriteString(assertion)
+		if uLen > 1 && i < uLen-1 {
+			whereBuilder.WriteString("AND ")
+		}
+		i++
+	}
+	query = querySkeleton + strings.TrimSpace(whereBuilder.String()) + ";"
+	return query, valueOrder
+}
+
+// getPrimaryKeyColumns runs a query that selects the primary key column names of a table in order
+func getPrimaryKeyColumns(db Database, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.GetDatabaseReference().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err = rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, rows.Err()
+}
+
+func noRowIdentityError(tableName string) error {
+	return fmt.Errorf("table %s %w", tableName, ErrNoRowIdentity)
+}
+
+func readOnlyError(tableName string) error {
+	return fmt.Errorf("%s %w", tableName, ErrReadOnly)
+}
+
+// getColumns runs a query that selects name, type, not null and default for each column of a table,
+// then marks the primary key columns using the table's row identity
+func getColumns(db Database, tableName, query string, args ...interface{}) ([]ColumnInfo, error) {
+	rows, err := db.GetDatabaseReference().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []ColumnInfo
+	for rows.Next() {
+		var c ColumnInfo
+		if err = rows.Scan(&c.Name, &c.Type, &c.NotNull, &c.Default); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if identity, err := db.GetRowIdentity(tableName); err == nil {
+		for i, k := range identity.Columns {
+			for c := range columns {
+				if columns[c].Name == k {
+					columns[c].PrimaryKey = i + 1
+				}
+			}
+		}
+	}
+
+	return columns, nil
 }
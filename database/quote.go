@@ -0,0 +1,32 @@
+package database
+
+import (
+	"strings"
+)
+
+// quoteIdentifier wraps name in quote, doubling up any quote characters inside of it so that
+// spaces, reserved words and quotes in table/column names can't break out of the identifier
+func quoteIdentifier(name, quote string) string {
+	return quote + strings.ReplaceAll(name, quote, quote+quote) + quote
+}
+
+// splitQualifiedName splits schema.table at the first dot, the schema is empty for unqualified names
+func splitQualifiedName(name string) (string, string) {
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) == 1 {
+		return "", name
+	}
+
+	return parts[0], parts[1]
+}
+
+// quoteQualifiedName quotes schema.table names one part at a time. Only the first dot separates
+// the schema, so table names with dots in them still work.
+func quoteQualifiedName(name, quote string) string {
+	schema, table := splitQualifiedName(name)
+	if schema == "" {
+		return quoteIdentifier(table, quote)
+	}
+
+	return quoteIdentifier(schema, quote) + "." + quoteIdentifier(table, quote)
+}
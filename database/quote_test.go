@@ -0,0 +1,168 @@
+package database
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var (
+	testSQLite   = &SQLite{Attached: []AttachedDatabase{{Alias: "aux"}}}
+	testMySQL    = &MySQL{}
+	testPostgres = &Postgres{}
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		sqlite   string
+		mysql    string
+		postgres string
+	}{
+		{"plain", `"plain"`, "`plain`", `"plain"`},
+		{"with space", `"with space"`, "`with space`", `"with space"`},
+		{"order", `"order"`, "`order`", `"order"`},
+		{"select", `"select"`, "`select`", `"select"`},
+		{`say "hi"`, `"say ""hi"""`, "`say \"hi\"`", `"say ""hi"""`},
+		{"back`tick", "\"back`tick\"", "`back``tick`", "\"back`tick\""},
+		{"a.b", `"a.b"`, "`a.b`", `"a.b"`},
+		{`"; DROP TABLE t; --`, `"""; DROP TABLE t; --"`, "`\"; DROP TABLE t; --`", `"""; DROP TABLE t; --"`},
+	}
+	for _, test := range tests {
+		if got := testSQLite.QuoteIdentifier(test.name); got != test.sqlite {
+			t.Errorf("SQLite.QuoteIdentifier(%q) = %s, want %s", test.name, got, test.sqlite)
+		}
+		if got := testMySQL.QuoteIdentifier(test.name); got != test.mysql {
+			t.Errorf("MySQL.QuoteIdentifier(%q) = %s, want %s", test.name, got, test.mysql)
+		}
+		if got := testPostgres.QuoteIdentifier(test.name); got != test.postgres {
+			t.Errorf("Postgres.QuoteIdentifier(%q) = %s, want %s", test.name, got, test.postgres)
+		}
+	}
+}
+
+func TestQuoteTableName(t *testing.T) {
+	tests := []struct {
+		name     string
+		sqlite   string
+		mysql    string
+		postgres string
+	}{
+		{"orders", `"orders"`, "`orders`", `"orders"`},
+		{"order", `"order"`, "`order`", `"order"`},
+		{"my table", `"my table"`, "`my table`", `"my table"`},
+		// only attached databases are schemas in sqlite, otherwise the dot is part of the name
+		{"aux.orders", `"aux"."orders"`, "`aux.orders`", `"aux"."orders"`},
+		{"v1.2", `"v1.2"`, "`v1.2`", `"v1"."2"`},
+		{"public.a.b", `"public.a.b"`, "`public.a.b`", `"public"."a.b"`},
+		{`we"ird.t"able`, `"we""ird.t""able"`, "`we\"ird.t\"able`", `"we""ird"."t""able"`},
+		{"aux.back`tick", "\"aux\".\"back`tick\"", "`aux.back``tick`", "\"aux\".\"back`tick\""},
+	}
+	for _, test := range tests {
+		if got := testSQLite.QuoteTableName(test.name); got != test.sqlite {
+			t.Errorf("SQLite.QuoteTableName(%q) = %s, want %s", test.name, got, test.sqlite)
+		}
+		if got := testMySQL.QuoteTableName(test.name); got != test.mysql {
+			t.Errorf("MySQL.QuoteTableName(%q) = %s, want %s", test.name, got, test.mysql)
+		}
+		if got := testPostgres.QuoteTableName(test.name); got != test.postgres {
+			t.Errorf("Postgres.QuoteTableName(%q) = %s, want %s", test.name, got, test.postgres)
+		}
+	}
+}
+
+func TestGenerateUpdateQuery(t *testing.T) {
+	tests := []struct {
+		db    Database
+		table string
+		want  string
+	}{
+		{testSQLite, "select", `UPDATE "select" SET "my ""col"""=? WHERE "id"=? AND "order"=?;`},
+		{testMySQL, "select", "UPDATE `select` SET `my \"col\"`=? WHERE `id`=? AND `order`=?;"},
+		{testPostgres, "sales.select", `UPDATE "sales"."select" SET "my ""col"""=$1 WHERE "id"=$2 AND "order"=$3;`},
+	}
+	for _, test := range tests {
+		u := &Update{Column: `my "col"`, Update: "x", TableName: test.table}
+		u.SetValues(map[string]interface{}{"order": 2, "id": 1})
+		query, order := test.db.GenerateQuery(u)
+		if query != test.want {
+			t.Errorf("%T.GenerateQuery = %s, want %s", test.db, query, test.want)
+		}
+		if want := []string{`my "col"`, "id", "order"}; !reflect.DeepEqual(order, want) {
+			t.Errorf("%T.GenerateQuery value order = %v, want %v", test.db, order, want)
+		}
+	}
+}
+
+func TestGenerateInsertQuery(t *testing.T) {
+	tests := []struct {
+		db    Database
+		table string
+		empty string
+		want  string
+	}{
+		{testSQLite, "aux.my table", "DEFAULT VALUES", `INSERT INTO "aux"."my table" ("a b", "from", "q""uote") VALUES (?, ?, ?)`},
+		{testMySQL, "my table", "() VALUES ()", "INSERT INTO `my table` (`a b`, `from`, `q\"uote`) VALUES (?, ?, ?)"},
+		{testPostgres, "public.my table", "DEFAULT VALUES", `INSERT INTO "public"."my table" ("a b", "from", "q""uote") VALUES ($1, $2, $3)`},
+	}
+	for _, test := range tests {
+		q := &Insert{TableName: test.table}
+		q.SetValues(map[string]interface{}{"from": 2, `q"uote`: nil, "a b": "x"})
+		query, values := generateInsertQuery(test.db, q, test.empty)
+		if query != test.want {
+			t.Errorf("generateInsertQuery(%T) = %s, want %s", test.db, query, test.want)
+		}
+		if want := []interface{}{"x", 2, nil}; !reflect.DeepEqual(values, want) {
+			t.Errorf("generateInsertQuery(%T) values = %v, want %v", test.db, values, want)
+		}
+
+		q.SetValues(map[string]interface{}{})
+		if query, _ := generateInsertQuery(test.db, q, test.empty); query != "INSERT INTO "+test.db.QuoteTableName(test.table)+" "+test.empty {
+			t.Errorf("generateInsertQuery(%T) with no values = %s", test.db, query)
+		}
+	}
+}
+
+func TestGenerateDeleteQuery(t *testing.T) {
+	tests := []struct {
+		db   Database
+		want string
+	}{
+		{testSQLite, `DELETE FROM "group" WHERE "a.b"=? AND "rowid"=?;`},
+		{testMySQL, "DELETE FROM `group` WHERE `a.b`=? AND `rowid`=?;"},
+		{testPostgres, `DELETE FROM "group" WHERE "a.b"=$1 AND "rowid"=$2;`},
+	}
+	for _, test := range tests {
+		q := &Delete{TableName: "group"}
+		q.SetValues(map[string]interface{}{"rowid": int64(7), "a.b": "x"})
+		query, values := generateDeleteQuery(test.db, q)
+		if query != test.want {
+			t.Errorf("generateDeleteQuery(%T) = %s, want %s", test.db, query, test.want)
+		}
+		if want := []interface{}{"x", int64(7)}; !reflect.DeepEqual(values, want) {
+			t.Errorf("generateDeleteQuery(%T) values = %v, want %v", test.db, values, want)
+		}
+	}
+
+	if _, err := testSQLite.Delete(&Delete{TableName: "group"}); err == nil {
+		t.Error("deleting without a row identity should fail")
+	}
+}
+
+func TestReadOnlyURI(t *testing.T) {
+	tests := []struct {
+		fileName string
+		want     string
+	}{
+		{"/tmp/shop.db", "file:///tmp/shop.db?mode=ro"},
+		{"/tmp/my dir/a#b?%.db", "file:///tmp/my%20dir/a%23b%3F%25.db?mode=ro"},
+	}
+	for _, test := range tests {
+		if got := readOnlyURI(test.fileName); got != test.want {
+			t.Errorf("readOnlyURI(%q) = %s, want %s", test.fileName, got, test.want)
+		}
+	}
+	if got := readOnlyURI("shop.db"); !strings.HasPrefix(got, "file:///") || !strings.HasSuffix(got, "/shop.db?mode=ro") {
+		t.Errorf("readOnlyURI(shop.db) = %s, want the absolute path", got)
+	}
+}
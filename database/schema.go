@@ -0,0 +1,155 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// TableSchema is everything the schema declares about a table
+type TableSchema struct {
+	Name        string
+	Columns     []ColumnInfo
+	ForeignKeys []ForeignKey
+	Indexes     []IndexInfo
+	Triggers    []TriggerInfo
+	Create      string // CREATE TABLE statement, as stored by the database or rebuilt from the catalog
+	Identity    []IdentityColumn
+}
+
+// IdentityColumn is a postgres column numbered by a sequence, declared as serial or as an identity column
+type IdentityColumn struct {
+	Name   string
+	Always bool // GENERATED ALWAYS, values can only be given with OVERRIDING SYSTEM VALUE
+}
+
+// ForeignKey is a reference from some columns of a table to the key of another table
+type ForeignKey struct {
+	Name              string   // constraint name, empty if it doesn't have one
+	Columns           []string // referencing columns, in order
+	Table             string   // referenced table, named the same way as GetTableNamesQuery does
+	ReferencedColumns []string // referenced columns, lined up with Columns. Empty when it refers to the primary key
+	OnUpdate          string
+	OnDelete          string
+}
+
+// IndexInfo is an index on a table
+type IndexInfo struct {
+	Name    string
+	Columns []string // column names, or expressions for expression indexes
+	Unique  bool
+	Primary bool   // backs the primary key
+	SQL     string // CREATE INDEX statement, empty for implicit indexes
+}
+
+// TriggerInfo is a trigger on a table
+type TriggerInfo struct {
+	Name string
+	SQL  string
+}
+
+// GetReferencedColumns is the referenced columns of a foreign key, falling back to the primary key of the referenced table
+func (fk ForeignKey) GetReferencedColumns(db Database) ([]string, error) {
+	if len(fk.ReferencedColumns) > 0 {
+		return fk.ReferencedColumns, nil
+	}
+
+	identity, err := db.GetRowIdentity(fk.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	return identity.Columns, nil
+}
+
+// queryStrings runs a query selecting string columns and returns every row of them. NULLs come back empty
+func queryStrings(db Database, query string, args ...interface{}) ([][]string, error) {
+	rows, err := db.GetDatabaseReference().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var result [][]string
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		row := make([]string, len(columns))
+		for i, v := range values {
+			row[i] = v.String
+		}
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+// groupForeignKeys folds rows of (name, column, table, referenced column, on update, on delete), one per column, into
+// foreign keys. Rows of the same key have to be next to each other, in column order
+func groupForeignKeys(rows [][]string) []ForeignKey {
+	var keys []ForeignKey
+	for i, r := range rows {
+		if i == 0 || r[0] != rows[i-1][0] || r[2] != rows[i-1][2] {
+			keys = append(keys, ForeignKey{
+				Name:     r[0],
+				Table:    r[2],
+				OnUpdate: r[4],
+				OnDelete: r[5],
+			})
+		}
+		fk := &keys[len(keys)-1]
+		fk.Columns = append(fk.Columns, r[1])
+		if r[3] != "" {
+			fk.ReferencedColumns = append(fk.ReferencedColumns, r[3])
+		}
+	}
+
+	for i := range keys { // references to the primary key don't name the columns, don't mix the two
+		if len(keys[i].ReferencedColumns) != len(keys[i].Columns) {
+			keys[i].ReferencedColumns = nil
+		}
+	}
+
+	return keys
+}
+
+// groupIndexes folds rows of (name, column, unique, primary, sql), one per column, into indexes.
+// Rows of the same index have to be next to each other, in column order
+func groupIndexes(rows [][]string) []IndexInfo {
+	var indexes []IndexInfo
+	for i, r := range rows {
+		if i == 0 || r[0] != rows[i-1][0] {
+			indexes = append(indexes, IndexInfo{
+				Name:    r[0],
+				Unique:  isTrue(r[2]),
+				Primary: isTrue(r[3]),
+				SQL:     r[4],
+			})
+		}
+		index := &indexes[len(indexes)-1]
+		index.Columns = append(index.Columns, r[1])
+	}
+
+	return indexes
+}
+
+// isTrue reads a boolean the way the different drivers hand them back as text
+func isTrue(s string) bool {
+	switch strings.ToLower(s) {
+	case "1", "t", "true", "yes":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// likeEscape is the escape character for LIKE patterns, one that means nothing special to any of the dialects
+const likeEscape = "!"
+
+// TextSearchCondition builds a condition matching rows where any of the columns contains text, ignoring case.
+// Placeholders are numbered from position, the values to bind are returned alongside.
+func TextSearchCondition(db Database, columns []string, text string, position int) (string, []interface{}) {
+	castType := "text"
+	if _, ok := db.(*MySQL); ok {
+		castType = "char"
+	}
+
+	escaped := strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_").
+		Replace(strings.ToLower(text))
+	pattern := "%" + escaped + "%"
+
+	var (
+		conditions []string
+		values     []interface{}
+	)
+	for i, c := range columns {
+		conditions = append(conditions, fmt.Sprintf("lower(cast(%s as %s)) like %s escape '%s'",
+			db.QuoteIdentifier(c), castType, db.GetPlaceholderForDatabaseType(position+i), likeEscape))
+		values = append(values, pattern)
+	}
+	if len(conditions) == 0 {
+		return "1 = 0", nil
+	}
+
+	return "(" + strings.Join(conditions, " or ") + ")", values
+}
+
+// IsTextType is whether a declared column type holds text. Columns without a declared type count, sqlite lets them hold anything
+func IsTextType(declared string) bool {
+	declared = strings.ToLower(declared)
+	if declared == "" || strings.HasPrefix(declared, "enum") || strings.HasPrefix(declared, "set(") {
+		return true
+	}
+	for _, t := range []string{"char", "text", "clob", "json", "uuid", "xml", "string"} {
+		if strings.Contains(declared, t) {
+			return true
+		}
+	}
+
+	return false
+}
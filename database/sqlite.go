@@ -3,47 +3,30 @@ package database
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"net/url"
+	"path/filepath"
 	"strings"
 )
 
 type SQLite struct {
 	FileName string
+	Options  string // URI parameters carried over to every copy of the file, e.g. mode=ro
 	Database *sql.DB
+	Attached []AttachedDatabase // other database files attached to this one, their tables are named alias.table
 }
 
-func (db *SQLite) Update(q *Update) {
-	protoQuery, columnOrder := db.GenerateQuery(q)
-	values := make([]interface{}, len(columnOrder))
-	updateValues := q.GetValues()
-	for i, v := range columnOrder {
-		var u interface{}
-		if i == 0 {
-			u = q.Update
-		} else {
-			u = updateValues[v]
-		}
+// AttachedDatabase is a database file attached under an alias with ATTACH DATABASE
+type AttachedDatabase struct {
+	Alias    string
+	FileName string
+}
 
-		if u == nil {
-			u = "NULL"
-		}
+func (db *SQLite) Update(q *Update) (int64, error) {
+	return executeUpdate(db, q)
+}
 
-		values[i] = u
-	}
-	tx, err := db.GetDatabaseReference().Begin()
-	if err != nil {
-		log.Fatal(err)
-	}
-	stmt, err := tx.Prepare(protoQuery)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer stmt.Close()
-	stmt.Exec(values...)
-	err = tx.Commit()
-	if err != nil {
-		log.Fatal(err)
-	}
+func (db *SQLite) Delete(q *Delete) (int64, error) {
+	return executeDelete(db, q)
 }
 
 func (db *SQLite) GetFileName() string {
@@ -55,54 +38,257 @@ func (db *SQLite) GetDatabaseReference() *sql.DB {
 }
 
 func (db *SQLite) CloseDatabaseReference() {
-	db.GetDatabaseReference().Close()
+	CloseDatabaseForFile(SQLiteDSN(db.FileName, db.Options))
 	db.Database = nil
 }
 
+// SetDatabaseReference also attaches the attached databases to the new connection, attachments don't outlive it
 func (db *SQLite) SetDatabaseReference(dbPath string) {
-	database := GetDatabaseForFile(dbPath)
+	database := GetDatabaseForFile(SQLiteDSN(dbPath, db.Options))
 	db.FileName = dbPath
 	db.Database = database
+	for _, a := range db.Attached {
+		database.Exec("ATTACH DATABASE ? AS "+quoteIdentifier(a.Alias, `"`), readOnlyURI(a.FileName)) // fails if it's still attached, which is fine
+	}
+}
+
+// Attach attaches another database file under alias, so its tables can be viewed and queried as alias.table.
+// It's opened read-only, changes to it would get past undo and saving, which only cover the main database
+func (db *SQLite) Attach(fileName, alias string) error {
+	switch strings.ToLower(alias) {
+	case "main", "temp":
+		return fmt.Errorf("%s is reserved, pick another alias", alias)
+	}
+	for _, a := range db.Attached {
+		if strings.EqualFold(a.Alias, alias) {
+			return fmt.Errorf("%s is already attached as %s", a.FileName, a.Alias)
+		}
+	}
+
+	if _, err := db.Database.Exec("ATTACH DATABASE ? AS "+quoteIdentifier(alias, `"`), readOnlyURI(fileName)); err != nil {
+		return err
+	}
+	db.Attached = append(db.Attached, AttachedDatabase{
+		Alias:    alias,
+		FileName: fileName,
+	})
+
+	return nil
+}
+
+// readOnlyURI is a file: URI that opens fileName read-only, escaping characters that mean something in URIs
+func readOnlyURI(fileName string) string {
+	if abs, err := filepath.Abs(fileName); err == nil { // relative paths would be read as a host
+		fileName = abs
+	}
+	path := filepath.ToSlash(fileName)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // windows drive letters, file:///C:/...
+	}
+
+	return (&url.URL{Scheme: "file", Path: path, RawQuery: "mode=ro"}).String()
+}
+
+// splitTableName gets the schema a table is in and its name within it. Only attached aliases count as a schema,
+// so tables of the main database can have dots in their names
+func (db SQLite) splitTableName(name string) (string, string) {
+	schema, table := splitQualifiedName(name)
+	for _, a := range db.Attached {
+		if schema != "" && a.Alias == schema {
+			return schema, table
+		}
+	}
+
+	return "main", name
+}
+
+// IsAttachedTable is whether a table is in an attached database rather than the main one
+func (db SQLite) IsAttachedTable(name string) bool {
+	schema, _ := db.splitTableName(name)
+	return schema != "main"
 }
 
-func (db SQLite) GetPlaceholderForDatabaseType() string {
+func (db SQLite) GetPlaceholderForDatabaseType(position int) string {
 	return "?"
 }
 
+func (db SQLite) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, `"`)
+}
+
+func (db SQLite) QuoteTableName(name string) string {
+	schema, table := db.splitTableName(name)
+	if schema == "main" {
+		return quoteIdentifier(name, `"`)
+	}
+
+	return quoteIdentifier(schema, `"`) + "." + quoteIdentifier(table, `"`)
+}
+
+// GetTableNamesQuery lists the tables and views of the main database, then those of each attached one.
+// Internal tables like sqlite_sequence are left out
 func (db SQLite) GetTableNamesQuery() string {
-	val := "SELECT name FROM "
-	val += "sqlite_master"
-	val += " WHERE type='table'"
+	val := sqliteTableNamesQuery("main", "")
+	for _, a := range db.Attached {
+		val += " UNION ALL " + sqliteTableNamesQuery(a.Alias, a.Alias+".")
+	}
+
+	return val
+}
+
+func sqliteTableNamesQuery(schema, prefix string) string {
+	master := quoteIdentifier(schema, `"`) + ".sqlite_master"
+	val := "SELECT " + quoteIdentifier(prefix, "'") + " || name,"
+	val += " CASE WHEN type = 'view' THEN '" + TableKindView + "'"
+	val += " WHEN sql LIKE 'CREATE VIRTUAL TABLE%' THEN '" + TableKindVirtual + "'"
+	val += " WHEN EXISTS (SELECT 1 FROM " + master + " v WHERE v.sql LIKE 'CREATE VIRTUAL TABLE%'"
+	val += " AND substr(t.name, 1, length(v.name) + 1) = v.name || '_') THEN '" + TableKindShadow + "'"
+	val += " ELSE '" + TableKindTable + "' END"
+	val += " FROM " + master + " t"
+	val += " WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite!_%' ESCAPE '!'"
 
 	return val
 }
 
 func (db *SQLite) GenerateQuery(u *Update) (string, []string) {
-	var (
-		query         string
-		querySkeleton string
-		valueOrder    []string
-	)
-
-	placeholder := db.GetPlaceholderForDatabaseType()
-
-	querySkeleton = fmt.Sprintf("UPDATE %s"+
-		" SET %s=%s ", u.TableName, u.Column, placeholder)
-	valueOrder = append(valueOrder, u.Column)
-
-	whereBuilder := strings.Builder{}
-	whereBuilder.WriteString(" WHERE ")
-	uLen := len(u.GetValues())
-	i := 0
-	for k := range u.GetValues() { // keep track of order since maps aren't deterministic
-		assertion := fmt.Sprintf("%s=%s ", k, placeholder)
-		valueOrder = append(valueOrder, k)
-		whereBuilder.WriteString(assertion)
-		if uLen > 1 && i < uLen-1 {
-			whereBuilder.WriteString("AND ")
+	return generateUpdateQuery(db, u)
+}
+
+// GetRowIdentity prefers the declared primary key and falls back to whichever rowid alias isn't
+// shadowed by a real column
+func (db *SQLite) GetRowIdentity(tableName string) (*RowIdentity, error) {
+	schema, table := db.splitTableName(tableName)
+	var kind string
+	db.GetDatabaseReference().QueryRow("SELECT type FROM "+quoteIdentifier(schema, `"`)+".sqlite_master WHERE name = ?", table).
+		Scan(&kind)
+	if kind == "view" { // views don't have a rowid
+		return nil, readOnlyError(tableName)
+	}
+
+	columns, err := db.GetColumns(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	var keys []string
+	for _, c := range columns {
+		names[strings.ToLower(c.Name)] = true
+		if c.PrimaryKey > 0 { // pk is the 1-based position within the key
+			for len(keys) < c.PrimaryKey {
+				keys = append(keys, "")
+			}
+			keys[c.PrimaryKey-1] = c.Name
+		}
+	}
+
+	if len(keys) > 0 {
+		return &RowIdentity{
+			Columns: keys,
+		}, nil
+	}
+
+	for _, alias := range []string{"rowid", "oid", "_rowid_"} {
+		if !names[alias] {
+			return &RowIdentity{
+				Columns: []string{alias},
+				Hidden:  true,
+			}, nil
+		}
+	}
+
+	return nil, noRowIdentityError(tableName)
+}
+
+func (db *SQLite) GetColumns(tableName string) ([]ColumnInfo, error) {
+	schema, table := db.splitTableName(tableName)
+	rows, err := db.GetDatabaseReference().Query(
+		`SELECT name, type, "notnull", dflt_value, pk FROM pragma_table_info(?, ?) ORDER BY cid`, table, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []ColumnInfo
+	for rows.Next() {
+		var c ColumnInfo
+		if err = rows.Scan(&c.Name, &c.Type, &c.NotNull, &c.Default, &c.PrimaryKey); err != nil {
+			return nil, err
 		}
-		i++
+		columns = append(columns, c)
+	}
+
+	return columns, rows.Err()
+}
+
+func (db *SQLite) Insert(q *Insert) (int64, error) {
+	return executeInsert(db, q, "DEFAULT VALUES")
+}
+
+func (db *SQLite) GetForeignKeys(tableName string) ([]ForeignKey, error) {
+	dbName, table := db.splitTableName(tableName)
+	rows, err := queryStrings(db, `SELECT id, "from", "table", "to", on_update, on_delete`+
+		` FROM pragma_foreign_key_list(?, ?) ORDER BY id, seq`, table, dbName)
+	if err != nil {
+		return nil, err
 	}
-	query = querySkeleton + strings.TrimSpace(whereBuilder.String()) + ";"
-	return query, valueOrder
+
+	keys := groupForeignKeys(rows)
+	for i := range keys {
+		keys[i].Name = "" // the id isn't a name, sqlite doesn't keep constraint names around
+		if dbName != "main" {
+			keys[i].Table = dbName + "." + keys[i].Table // references stay within the attached database
+		}
+	}
+
+	return keys, nil
+}
+
+func (db *SQLite) GetSchema(tableName string) (*TableSchema, error) {
+	schema := &TableSchema{
+		Name: tableName,
+	}
+
+	var err error
+	if schema.Columns, err = db.GetColumns(tableName); err != nil {
+		return nil, err
+	}
+
+	if schema.ForeignKeys, err = db.GetForeignKeys(tableName); err != nil {
+		return nil, err
+	}
+
+	dbName, table := db.splitTableName(tableName)
+	master := quoteIdentifier(dbName, `"`) + ".sqlite_master"
+
+	rows, err := queryStrings(db, `SELECT il.name, coalesce(ii.name, '(expression)'), il."unique", il.origin = 'pk', m.sql`+
+		` FROM pragma_index_list(?1, ?2) il JOIN pragma_index_info(il.name, ?2) ii`+
+		` LEFT JOIN `+master+` m ON m.type = 'index' AND m.name = il.name`+
+		` ORDER BY il.name, ii.seqno`, table, dbName)
+	if err != nil {
+		return nil, err
+	}
+	schema.Indexes = groupIndexes(rows)
+
+	rows, err = queryStrings(db, `SELECT name, sql FROM `+master+` WHERE type = 'trigger' AND tbl_name = ? ORDER BY name`,
+		table)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		schema.Triggers = append(schema.Triggers, TriggerInfo{
+			Name: r[0],
+			SQL:  r[1],
+		})
+	}
+
+	rows, err = queryStrings(db, `SELECT sql FROM `+master+` WHERE name = ? AND type IN ('table', 'view')`, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 {
+		schema.Create = rows[0][0]
+	}
+
+	return schema, nil
 }
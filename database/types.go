@@ -0,0 +1,169 @@
+package database
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ColumnKind is what kind of value a column holds, worked out from its declared type
+type ColumnKind int
+
+const (
+	KindAny ColumnKind = iota // no declared type, sqlite columns like that take anything
+	KindText
+	KindInteger
+	KindReal
+	KindNumeric // decimal and friends, kept as text so they don't lose precision
+	KindBool
+	KindTime
+	KindBlob
+)
+
+var (
+	// TimeLayouts are tried in order when parsing input for date/time columns, see the -l flag
+	TimeLayouts = []string{
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.999999999-07:00",
+		"2006-01-02 15:04:05.999999999 -0700 MST", // time.Time.String, which is how they're shown
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02 15:04",
+		"2006-01-02",
+		"15:04:05",
+	}
+)
+
+// GetColumnKind works out the kind of a declared column type. It goes by the same substrings sqlite uses for type affinity,
+// checking the ones other databases use as well
+func GetColumnKind(declared string) ColumnKind {
+	t := strings.ToLower(strings.TrimSpace(declared))
+	contains := func(s ...string) bool {
+		for _, v := range s {
+			if strings.Contains(t, v) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case t == "":
+		return KindAny
+	case contains("bool") || t == "tinyint(1)" || t == "bit(1)":
+		return KindBool
+	case contains("interval", "point"): // have "int" in them but aren't integers
+		return KindText
+	case contains("date", "time"):
+		return KindTime
+	case contains("int"):
+		return KindInteger
+	case contains("char", "clob", "text"):
+		return KindText
+	case contains("blob", "bytea", "binary"):
+		return KindBlob
+	case contains("real", "floa", "doub"):
+		return KindReal
+	case contains("numeric", "decimal", "money"):
+		return KindNumeric
+	}
+
+	return KindText
+}
+
+// ParseValue turns what was typed into a cell into a value for a column of the given declared type.
+// Input that doesn't fit the type is an error rather than a zero value
+func ParseValue(db Database, declared string, input string) (interface{}, error) {
+	trimmed := strings.TrimSpace(input)
+	switch GetColumnKind(declared) {
+	case KindInteger:
+		i, err := strconv.ParseInt(trimmed, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q isn't an integer", input)
+		}
+		return i, nil
+	case KindReal:
+		f, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q isn't a number", input)
+		}
+		return f, nil
+	case KindNumeric:
+		if _, err := strconv.ParseFloat(trimmed, 64); err != nil {
+			return nil, fmt.Errorf("%q isn't a number", input)
+		}
+		return trimmed, nil
+	case KindBool:
+		switch strings.ToLower(trimmed) {
+		case "1", "t", "true", "y", "yes", "on":
+			return true, nil
+		case "0", "f", "false", "n", "no", "off":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q isn't true or false", input)
+	case KindTime:
+		return parseTime(db, trimmed)
+	case KindBlob:
+		return ParseBlob(input), nil
+	}
+
+	return input, nil
+}
+
+// parseTime reads input in the first of TimeLayouts that fits. sqlite has no date type, so there it stays text
+func parseTime(db Database, input string) (interface{}, error) {
+	for _, layout := range TimeLayouts {
+		t, err := time.Parse(layout, input)
+		if err != nil {
+			continue
+		}
+
+		if _, ok := db.(*SQLite); ok {
+			return t.Format(sqliteTimeLayout(layout)), nil
+		}
+		return t, nil
+	}
+
+	return nil, fmt.Errorf("%q isn't a date/time, expected something like %s", input, time.Now().Format("2006-01-02 15:04:05"))
+}
+
+// sqliteTimeLayout is how a time typed in layout gets stored in sqlite, in the ISO 8601 forms its date functions understand.
+// Only the parts the layout had are kept
+func sqliteTimeLayout(layout string) string {
+	hasClock := strings.Contains(layout, "15") || strings.Contains(layout, "3")
+	hasDate := strings.Contains(layout, "06")
+	hasZone := strings.Contains(layout, "Z07") || strings.Contains(layout, "-07") || strings.Contains(layout, "MST")
+	switch {
+	case !hasClock:
+		return "2006-01-02"
+	case !hasDate:
+		return "15:04:05.999999999"
+	case hasZone:
+		return "2006-01-02 15:04:05.999999999-07:00"
+	}
+
+	return "2006-01-02 15:04:05.999999999"
+}
+
+// ParseBlob reads x'...' or 0x... as hex, anything else is taken as raw bytes
+func ParseBlob(input string) []byte {
+	trimmed := strings.TrimSpace(input)
+	lower := strings.ToLower(trimmed)
+	var digits string
+	switch {
+	case strings.HasPrefix(lower, "x'") && strings.HasSuffix(lower, "'") && len(lower) >= 3:
+		digits = trimmed[2 : len(trimmed)-1]
+	case strings.HasPrefix(lower, "0x"):
+		digits = trimmed[2:]
+	default:
+		return []byte(input)
+	}
+
+	if b, err := hex.DecodeString(digits); err == nil {
+		return b
+	}
+
+	return []byte(input)
+}
@@ -1,11 +1,11 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"flag"
 	"fmt"
 	"io/fs"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,6 +15,8 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
 	"github.com/mathaou/termdbms/database"
 	"github.com/muesli/termenv"
 	_ "modernc.org/sqlite"
@@ -27,17 +29,29 @@ const (
 )
 
 const (
-	DatabaseSQLite DatabaseType = "sqlite"
-	DatabaseMySQL  DatabaseType = "mysql"
+	DatabaseSQLite   DatabaseType = database.DriverSQLite
+	DatabaseMySQL    DatabaseType = database.DriverMySQL
+	DatabasePostgres DatabaseType = database.DriverPostgres
 )
 
 var (
-	debug        bool
-	path         string
-	databaseType string
-	theme        string
-	help         bool
-	ascii        bool
+	debug         bool
+	path          string
+	databaseType  string
+	theme         string
+	help          bool
+	ascii         bool
+	timeLayouts   string
+	dumpFile      string
+	dumpTable     string
+	dumpDialect   string
+	csvTypes      string
+	csvDelimiter  string
+	csvComment    string
+	csvEncoding   string
+	csvLazyQuotes bool
+	csvHeader     bool
+	connection    *database.ConnectionInfo
 )
 
 func main() {
@@ -51,21 +65,35 @@ func main() {
 	}
 
 	argLength := len(os.Args[1:])
-	if (argLength > 4 || argLength == 0) && !debug {
+	if argLength == 0 && !debug {
 		fmt.Printf("ERROR: Invalid number of arguments supplied: %d\n", argLength)
 		flag.Usage()
 		os.Exit(1)
 	}
 
 	// flags declaration using flag package
-	flag.StringVar(&databaseType, "d", string(DatabaseSQLite), "Specifies the SQL driver to use. Defaults to SQLite.")
-	flag.StringVar(&path, "p", "", "Path to the database file.")
+	flag.StringVar(&databaseType, "d", "", "Specifies the SQL driver to use. Inferred from the connection string, defaults to SQLite.")
+	flag.StringVar(&path, "p", "", "Path to the database file, or a connection string (sqlite://, file:, mysql://, postgres://).")
 	flag.StringVar(&theme, "t", "default", "sets the color theme of the app.")
 	flag.BoolVar(&help, "h", false, "Prints the help message.")
 	flag.BoolVar(&ascii, "a", false, "Denotes that the app should render with minimal styling to remove ANSI sequences.")
+	flag.StringVar(&timeLayouts, "l", "", "Go time layouts, separated by ;, tried before the built in ones when editing date/time columns.")
+	flag.StringVar(&csvTypes, "csv-types", "", "Column types for a csv file, like \"id=INTEGER,price=REAL\". The others are inferred from the first rows.")
+	flag.StringVar(&csvDelimiter, "csv-delimiter", "", "Delimiter of a csv file, a character or tab/comma/semicolon/pipe. Defaults to tab for .tsv, | for .psv and a comma otherwise.")
+	flag.StringVar(&csvComment, "csv-comment", "", "Lines of a csv file starting with this character are skipped.")
+	flag.StringVar(&csvEncoding, "csv-encoding", "", "Encoding of a csv file (utf-8/latin-1/utf-16/utf-16le/utf-16be), from the byte order mark when left out.")
+	flag.BoolVar(&csvLazyQuotes, "csv-lazy-quotes", false, "Lets quotes appear in unquoted fields of a csv file, and unescaped in quoted ones.")
+	flag.BoolVar(&csvHeader, "csv-header", true, "Whether the first line of a csv file has the column names, -csv-header=false names them col1..colN.")
+	flag.StringVar(&dumpFile, "dump", "", "Writes a SQL dump of the database to this file (- for stdout) and exits instead of opening the viewer.")
+	flag.StringVar(&dumpTable, "dump-table", "", "Only dumps this table.")
+	flag.StringVar(&dumpDialect, "dump-dialect", "", "Which database the dump is for (sqlite/mysql/postgres), defaults to the one being dumped.")
 
 	flag.Parse()
 
+	if debug {
+		path = debugPath
+	}
+
 	handleFlags()
 
 	var c *sql.Rows
@@ -75,10 +103,6 @@ func main() {
 		}
 	}()
 
-	if debug {
-		path = debugPath
-	}
-
 	for i, v := range ValidThemes {
 		if theme == v {
 			SelectedTheme = i
@@ -90,12 +114,6 @@ func main() {
 		theme = "default"
 	}
 
-	// gets a sqlite instance for the database file
-	if exists, _ := FileExists(path); exists {
-		fmt.Printf("ERROR: Database file could not be found at %s\n", path)
-		os.Exit(1)
-	}
-
 	if valid, _ := Exists(HiddenTmpDirectoryName); valid {
 		filepath.Walk(HiddenTmpDirectoryName, func(path string, info fs.FileInfo, err error) error {
 			if strings.HasPrefix(path, fmt.Sprintf("%s/.", HiddenTmpDirectoryName)) && !info.IsDir() {
@@ -107,41 +125,84 @@ func main() {
 		os.Mkdir(HiddenTmpDirectoryName, 0o777)
 	}
 
-	database.IsCSV = strings.HasSuffix(path, ".csv")
-	dst := path
-	if database.IsCSV { // convert the csv to sql, then run the sql through a database
-		sqlFile := strings.TrimSuffix(path, ".csv")
-		sqlFile = filepath.Base(sqlFile)
-		path = Convert(path, sqlFile, true)
-		csvDBFile := HiddenTmpDirectoryName + "/" + sqlFile + ".db"
-		os.Create(csvDBFile)
-		dst, _ = filepath.Abs(csvDBFile)
-		d, _ := sql.Open(database.DriverString, dst)
-		f, _ := os.Open(path)
-		b, _ := ioutil.ReadAll(f)
-		query := string(b)
-		_, err := d.Exec(query)
-		if err != nil {
-			fmt.Printf("%v", err)
+	if connection.Path != "" { // only file based databases get copied for undo/redo
+		path = connection.Path
+		dst := path
+		// gets a sqlite instance for the database file
+		if exists, _ := FileExists(path); exists {
+			fmt.Printf("ERROR: Database file could not be found at %s\n", path)
 			os.Exit(1)
 		}
-		d.Close()
-		os.Remove(path) // this deletes the converted .sql file
-	}
 
-	dst, _, _ = CopyFile(dst)
+		database.IsCSV = IsCSVFile(path)
+		if database.IsCSV { // stream the csv into a new database, which is what gets viewed
+			tableName := GetCSVTableName(path)
+			options, err := NewCSVOptions(path, csvDelimiter, csvComment, csvEncoding, csvTypes, csvLazyQuotes, csvHeader)
+			if err != nil {
+				fmt.Printf("ERROR: %v\n", err)
+				os.Exit(1)
+			}
+			csvDBFile := HiddenTmpDirectoryName + "/" + tableName + ".db"
+			os.Remove(csvDBFile)
+			dst, _ = filepath.Abs(csvDBFile)
+			d, err := sql.Open(database.DriverSQLite, dst)
+			if err == nil {
+				err = d.Ping() // opening doesn't touch the file yet
+			}
+			if err != nil {
+				fmt.Printf("ERROR: %v\n", err)
+				os.Exit(1)
+			}
+			reported := false
+			options.Progress = func(rows int, read, size int64) {
+				percent := int64(100)
+				if size > 0 {
+					percent = read * 100 / size
+				}
+				fmt.Fprintf(os.Stderr, "\rImporting %s: %d%% (%d rows)", path, percent, rows)
+				reported = true
+			}
+			_, err = ImportCSV(context.Background(), d, path, tableName, options)
+			if reported {
+				fmt.Fprintln(os.Stderr)
+			}
+			d.Close()
+			if err != nil {
+				fmt.Printf("ERROR: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		dst, _, _ = CopyFile(dst)
+		connection.SetPath(dst)
+	}
 
-	db := database.GetDatabaseForFile(dst)
+	db := database.GetDatabaseForFile(connection.DSN)
 	defer func() {
 		if db == nil {
 			db.Close()
 		}
 	}()
 
+	if dumpFile != "" { // no viewer, just the dump
+		count, err := DumpToFile(database.GetDatabaseImplementation(connection, db), dumpFile, dumpTable, dumpDialect)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		if dumpFile != "-" {
+			fmt.Printf("Wrote %d row(s) to %s\n", count, dumpFile)
+		}
+		return
+	}
+
 	// initializes the model used by bubbletea
-	m := GetNewModel(dst, db)
+	m := GetNewModel(connection, db)
 	InitialModel = &m
 	InitialModel.InitialFileName = path
+	if database.IsCSV { // saving writes the database, which never goes over the csv it came from
+		InitialModel.InitialFileName = strings.TrimSuffix(path, filepath.Ext(path)) + ".db"
+	}
 	err := InitialModel.SetModel(c, db)
 	if err != nil {
 		fmt.Printf("%v", err)
@@ -171,22 +232,36 @@ func handleFlags() {
 		os.Exit(0)
 	}
 
+	if timeLayouts != "" {
+		database.TimeLayouts = append(strings.Split(timeLayouts, ";"), database.TimeLayouts...)
+	}
+
 	if ascii {
 		Ascii = true
 		lipgloss.SetColorProfile(termenv.Ascii)
 	}
 
-	if path != "" && !IsUrl(path) {
-		fmt.Printf("ERROR: Invalid path %s\n", path)
+	if databaseType != "" &&
+		databaseType != string(DatabaseMySQL) &&
+		databaseType != string(DatabasePostgres) &&
+		databaseType != string(DatabaseSQLite) {
+		fmt.Printf("Invalid database driver specified: %s", databaseType)
+		os.Exit(1)
+	}
+
+	var err error
+	connection, err = database.ParseConnectionString(path, databaseType)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
 		flag.Usage()
 		os.Exit(1)
 	}
 
-	if databaseType != string(DatabaseMySQL) &&
-		databaseType != string(DatabaseSQLite) {
-		fmt.Printf("Invalid database driver specified: %s", databaseType)
+	if connection.Path != "" && !IsUrl(connection.Path) {
+		fmt.Printf("ERROR: Invalid path %s\n", path)
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	database.DriverString = databaseType
+	database.DriverString = connection.Driver
 }
@@ -1,14 +1,16 @@
 package tuiutil
 
 import (
-	"bufio"
-	"bytes"
+	"context"
+	"database/sql"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"strings"
+
+	"github.com/mathaou/termdbms/database"
 )
 
 /*
@@ -32,145 +34,236 @@ import (
    updated: 27 Aug 2016 - table name and csv file help output minior changes. Minor cosmetic stuff. Version 1.1
 */
 
-func SQLFileName(csvFileName string) string {
-	// include the name of the csv file from command line (ie csvFileName)
-	// remove any path etc
-	var justFileName = filepath.Base(csvFileName)
-	// get the files extension too
-	var extension = filepath.Ext(csvFileName)
-	// remove the file extension from the filename
-	justFileName = justFileName[0 : len(justFileName)-len(extension)]
-
-	sqlOutFile := "./.termdbms/SQL-" + justFileName + ".sql"
-	return sqlOutFile
+// countingReader keeps track of how much of a file has been read, for reporting progress
+type countingReader struct {
+	reader io.Reader
+	read   int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.read += int64(n)
+	return n, err
 }
 
-func Convert(csvFileName, tableName string, keepOrigCols bool) string {
+// ImportCSV reads a csv file into a new table tableName, with a column per header field and a row per line after it.
+// How the file is read (delimiter, quoting, encoding, whether there's a header) comes from options. Column types are inferred from the first options.SampleSize lines, unless options.Types gives them. Lines are
+// streamed into the database through a prepared INSERT, committed every options.BatchSize rows, so memory use doesn't
+// depend on the size of the file. The table is dropped again if the import fails or ctx is cancelled. Returns how
+// many rows were imported
+func ImportCSV(ctx context.Context, db *sql.DB, csvFileName, tableName string, options CSVOptions) (int, error) {
 	// check we have a table name and csv file to work with - otherwise abort
 	if csvFileName == "" || tableName == "" {
-		return ""
+		return 0, errors.New("no csv file or table name to import")
 	}
 
-	// open the CSV file - name provided via command line input - handle 'file'
 	file, err := os.Open(csvFileName)
-	// error - if we have one exit as CSV file not right
 	if err != nil {
-		fmt.Printf("ERROR: %s\n", err)
-		os.Exit(-3)
+		return 0, err
 	}
-	// now file is open - defer the close of CSV file handle until we return
 	defer file.Close()
-	// connect a CSV reader to the file handle - which is the actual opened
-	// CSV file
-	// TODO : is there an error from this to check?
-	reader := csv.NewReader(file)
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+	counter := &countingReader{reader: file}
+	decoder, err := NewCSVDecoder(counter, options.Encoding)
+	if err != nil {
+		return 0, err
+	}
+	reader := csv.NewReader(decoder)
+	if options.Delimiter != 0 {
+		reader.Comma = options.Delimiter
+	}
+	reader.Comment = options.Comment
+	reader.LazyQuotes = options.LazyQuotes
 
-	sqlOutFile := SQLFileName(csvFileName)
+	// the first line has the column names, unless there's no header and they're made up
+	header, err := reader.Read()
+	if err == io.EOF {
+		return 0, fmt.Errorf("%s is empty", csvFileName)
+	} else if err != nil {
+		return 0, err
+	}
+	var sample [][]string
+	if options.NoHeader {
+		sample = append(sample, header)
+		header = make([]string, len(header))
+	}
+	columns := GetCSVColumnNames(header, options.KeepOriginalColumns)
 
-	// open the new file using the name we obtained above - handle 'filesql'
-	filesql, err := os.Create(sqlOutFile)
-	// error - if we have one when trying open & create the new file
-	if err != nil {
-		return ""
-	}
-	// now new file is open - defer the close of the file handle until we return
-	defer filesql.Close()
-	// attach the opened new sql file handle to a buffered file writer
-	// the buffered file writer has the handle 'sqlFileBuffer'
-	sqlFileBuffer := bufio.NewWriter(filesql)
-
-	//-------------------------------------------------------------------------
-	// prepare to read the each line of the CSV file - and write out to the SQl
-	//-------------------------------------------------------------------------
-	// track the number of lines in the csv file
-	lineCount := 0
-
-	// create a buffer to hold each line of the SQL file as we build it
-	// handle to this buffer is called 'strbuffer'
-	var strbuffer bytes.Buffer
-
-	// START - processing of each line in the CSV input file
-	//-------------------------------------------------------------------------
-	// loop through the csv file until EOF - or until we hit an error in parsing it.
-	// Data is read in for each line of the csv file and held in the variable
-	// 'record'.  Build a string for each line - wrapped with the SQL and
-	// then output to the SQL file writer in its completed new form
-	//-------------------------------------------------------------------------
-	for {
+	// hold on to some lines to work out the types from, before anything gets written
+	sampleSize := options.SampleSize
+	if sampleSize <= 0 {
+		sampleSize = DefaultSampleSize
+	}
+	for len(sample) < sampleSize {
 		record, err := reader.Read()
-
-		// if we hit end of file (EOF) or another unexpected error
 		if err == io.EOF {
 			break
 		} else if err != nil {
-			return ""
-		}
-
-		// if we are processing the first line - use the record field contents
-		// as the SQL table column names - add to the temp string 'strbuffer'
-		// use the tablename provided by the user
-		if lineCount == 0 {
-			strbuffer.WriteString("CREATE TABLE " + tableName + " (")
-		}
-
-		// if any line except the first one :
-		// print the start of the SQL insert statement for the record
-		// and  - add to the temp string 'strbuffer'
-		// use the tablename provided by the user
-		if lineCount > 0 {
-			strbuffer.WriteString("INSERT INTO " + tableName + " VALUES (")
-		}
-		// loop through each of the csv lines individual fields held in 'record'
-		// len(record) tells us how many fields are on this line - so we loop right number of times
-		for i := 0; i < len(record); i++ {
-			// if we are processing the first line used for the table column name - update the
-			// record field contents to remove the characters: space | - + @ # / \ : ( ) '
-			// from the SQL table column names. Can be overridden on command line with '-k true'
-			if (lineCount == 0) && (keepOrigCols == false) {
-				// call the function cleanHeader to do clean up on this field
-				record[i] = cleanHeader(record[i])
-			}
-			// if a csv record field is empty or has the text "NULL" - replace it with actual NULL field in SQLite
-			// otherwise just wrap the existing content with ''
-			// TODO : make sure we don't try to create a 'NULL' table column name?
-			if len(record[i]) == 0 || record[i] == "NULL" {
-				strbuffer.WriteString("NULL")
-			} else {
-				strbuffer.WriteString("\"" + record[i] + "\"")
+			return 0, err
+		}
+		sample = append(sample, record)
+	}
+	reader.ReuseRecord = true // the sample is all that's kept around
+
+	for name := range options.Types {
+		found := false
+		for i := range columns {
+			found = found || name == columns[i] || name == header[i]
+		}
+		if !found {
+			return 0, fmt.Errorf("there's no column named %s in %s to give a type to", name, csvFileName)
+		}
+	}
+	types := InferCSVTypes(header, columns, sample, options.Types)
+
+	quotedTable := database.QuoteIdentifierForDriver(database.DriverSQLite, tableName)
+	var (
+		definitions  []string
+		placeholders []string
+	)
+	for i, c := range columns {
+		definitions = append(definitions, database.QuoteIdentifierForDriver(database.DriverSQLite, c)+" "+types[i])
+		placeholders = append(placeholders, "?")
+	}
+	if _, err = db.Exec("CREATE TABLE " + quotedTable + " (" + strings.Join(definitions, ", ") + ")"); err != nil {
+		return 0, err
+	}
+
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultImportBatchSize
+	}
+	insert := "INSERT INTO " + quotedTable + " VALUES (" + strings.Join(placeholders, ", ") + ")"
+	var (
+		tx    *sql.Tx
+		stmt  *sql.Stmt
+		count int
+	)
+	begin := func() error {
+		var err error
+		if tx, err = db.Begin(); err != nil {
+			return err
+		}
+		stmt, err = tx.Prepare(insert)
+		return err
+	}
+	commit := func() error {
+		stmt.Close()
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		if options.Progress != nil {
+			options.Progress(count, counter.read, size)
+		}
+		return nil
+	}
+
+	// a table with only some of the file in it is no use, it goes if anything fails
+	fail := func(err error) (int, error) {
+		if tx != nil {
+			tx.Rollback()
+		}
+		db.Exec("DROP TABLE " + quotedTable)
+		return 0, err
+	}
+
+	if err = begin(); err != nil {
+		return fail(err)
+	}
+	values := make([]interface{}, len(columns))
+	write := func(record []string) error {
+		for i, v := range record {
+			values[i] = ConvertCSVValue(v, types[i])
+		}
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return fmt.Errorf("row %d: %v", count+1, err)
+		}
+		count++
+		if count%batchSize == 0 {
+			if err := commit(); err != nil {
+				return err
 			}
-			// if we have not reached the last record yet - add a coma also to the output
-			if i < len(record)-1 {
-				strbuffer.WriteString(",")
+			return begin()
+		}
+		return nil
+	}
+
+	for _, record := range sample {
+		if err = write(record); err != nil {
+			return fail(err)
+		}
+	}
+	sample = nil
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err == nil {
+			err = write(record)
+		}
+		if err != nil {
+			return fail(err)
+		}
+	}
+	if err = commit(); err != nil {
+		return fail(err)
+	}
+
+	return count, nil
+}
+
+// GetCSVColumnNames makes column names out of a csv header. Empty names become colN, and repeated ones get a number
+func GetCSVColumnNames(header []string, keepOrigCols bool) []string {
+	columns := make([]string, len(header))
+	seen := make(map[string]bool)
+	for i, h := range header {
+		name := strings.TrimSpace(h)
+		if !keepOrigCols {
+			name = cleanHeader(name)
+		}
+		if name == "" {
+			name = fmt.Sprintf("col%d", i+1)
+		}
+		for n := 2; seen[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s_%d", strings.TrimSuffix(name, fmt.Sprintf("_%d", n-1)), n)
+		}
+		seen[strings.ToLower(name)] = true
+		columns[i] = name
+	}
+
+	return columns
+}
+
+// InferCSVTypes gets the type of every column, from types (by column or header name) or else from the sample
+func InferCSVTypes(header, columns []string, sample [][]string, types map[string]string) []string {
+	inferred := make([]string, len(columns))
+	for i, c := range columns {
+		if t, ok := types[c]; ok {
+			inferred[i] = t
+			continue
+		}
+		if t, ok := types[header[i]]; ok {
+			inferred[i] = t
+			continue
+		}
+
+		values := make([]string, 0, len(sample))
+		for _, record := range sample {
+			if i < len(record) {
+				values = append(values, record[i])
 			}
 		}
-		// end of the line - so output SQL format required ');' and newline
-		strbuffer.WriteString(");\n")
-		// line of SQL is complete - so push out to the new SQL file
-		bWritten, err := sqlFileBuffer.WriteString(strbuffer.String())
-		// check it wrote data ok - otherwise report the error giving the line number affected
-		if (err != nil) || (bWritten != len(strbuffer.Bytes())) {
-			return ""
-		}
-		// reset the string buffer - so it is empty ready for the next line to build
-		strbuffer.Reset()
-		// for debug - show the line number we are processing from the CSV file
-		// increment the line count - and loop back around for next line of the CSV file
-		lineCount += 1
-	}
-	// write out final line to the SQL file
-	bWritten, err := sqlFileBuffer.WriteString(strbuffer.String())
-	// check it wrote data ok - otherwise report the error giving the line number affected
-	if (err != nil) || (bWritten != len(strbuffer.Bytes())) {
-		return ""
-	}
-	strbuffer.WriteString("\nCOMMIT;")
-	// finished the SQl file data writing - flush any IO buffers
-	// NB below flush required as the data was being lost otherwise - maybe a bug in go version 1.2 only?
-	sqlFileBuffer.Flush()
-	// reset the string buffer - so it is empty as it is no longer needed
-	strbuffer.Reset()
-
-	return sqlOutFile
+		inferred[i] = InferColumnType(values)
+	}
+
+	return inferred
 }
 
 func cleanHeader(headField string) string {
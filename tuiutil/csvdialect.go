@@ -0,0 +1,167 @@
+package tuiutil
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// encodings csv files can be read in
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingLatin1  = "latin-1"
+	EncodingUTF16   = "utf-16" // byte order from the byte order mark, little endian without one
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+)
+
+// CSVExtensions are the files opened as csv, with the delimiter they use
+var CSVExtensions = map[string]rune{
+	".csv": ',',
+	".tsv": '\t',
+	".psv": '|',
+}
+
+// IsCSVFile is whether a file is opened as csv, going by its extension
+func IsCSVFile(fileName string) bool {
+	_, ok := CSVExtensions[strings.ToLower(filepath.Ext(fileName))]
+	return ok
+}
+
+// GetCSVDelimiter is the delimiter a file uses going by its extension, a comma for anything else
+func GetCSVDelimiter(fileName string) rune {
+	if r, ok := CSVExtensions[strings.ToLower(filepath.Ext(fileName))]; ok {
+		return r
+	}
+
+	return ','
+}
+
+// GetCSVTableName is the table a csv file goes into, its name without the extension
+func GetCSVTableName(fileName string) string {
+	base := filepath.Base(fileName)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// ParseEncoding checks an encoding given for a csv file, taking the common ways of writing them. Empty means it's
+// worked out from the byte order mark, utf-8 without one
+func ParseEncoding(s string) (string, error) {
+	switch strings.ReplaceAll(strings.ToLower(s), "_", "-") {
+	case "":
+		return "", nil
+	case "utf-8", "utf8":
+		return EncodingUTF8, nil
+	case "latin-1", "latin1", "iso-8859-1", "iso8859-1":
+		return EncodingLatin1, nil
+	case "utf-16", "utf16":
+		return EncodingUTF16, nil
+	case "utf-16le", "utf16le":
+		return EncodingUTF16LE, nil
+	case "utf-16be", "utf16be":
+		return EncodingUTF16BE, nil
+	}
+
+	return "", fmt.Errorf("%s isn't an encoding, use %s, %s, %s, %s or %s", s, EncodingUTF8, EncodingLatin1,
+		EncodingUTF16, EncodingUTF16LE, EncodingUTF16BE)
+}
+
+// NewCSVDecoder reads r as utf-8, whatever encoding it's in. A byte order mark is dropped, and picks the encoding
+// when none is given
+func NewCSVDecoder(r io.Reader, encoding string) (io.Reader, error) {
+	encoding, err := ParseEncoding(encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	src := bufio.NewReader(r)
+	bom, _ := src.Peek(3)
+	switch {
+	case len(bom) >= 3 && bom[0] == 0xEF && bom[1] == 0xBB && bom[2] == 0xBF:
+		if encoding == "" || encoding == EncodingUTF8 {
+			src.Discard(3)
+			encoding = EncodingUTF8
+		}
+	case len(bom) >= 2 && bom[0] == 0xFF && bom[1] == 0xFE:
+		if encoding == "" || encoding == EncodingUTF16 || encoding == EncodingUTF16LE {
+			src.Discard(2)
+			encoding = EncodingUTF16LE
+		}
+	case len(bom) >= 2 && bom[0] == 0xFE && bom[1] == 0xFF:
+		if encoding == "" || encoding == EncodingUTF16 || encoding == EncodingUTF16BE {
+			src.Discard(2)
+			encoding = EncodingUTF16BE
+		}
+	}
+
+	switch encoding {
+	case EncodingLatin1:
+		return &decodingReader{src: src, next: nextLatin1}, nil
+	case EncodingUTF16, EncodingUTF16LE:
+		return &decodingReader{src: src, next: nextUTF16(binary.LittleEndian)}, nil
+	case EncodingUTF16BE:
+		return &decodingReader{src: src, next: nextUTF16(binary.BigEndian)}, nil
+	}
+
+	return src, nil
+}
+
+// decodingReader turns what it reads into utf-8, one rune at a time
+type decodingReader struct {
+	src     *bufio.Reader
+	next    func(src *bufio.Reader) (rune, error)
+	pending []byte
+	err     error
+}
+
+func (d *decodingReader) Read(p []byte) (int, error) {
+	var buf [utf8.UTFMax]byte
+	for len(d.pending) < len(p) && d.err == nil {
+		var r rune
+		if r, d.err = d.next(d.src); d.err == nil {
+			d.pending = append(d.pending, buf[:utf8.EncodeRune(buf[:], r)]...)
+		}
+	}
+	if len(d.pending) == 0 {
+		return 0, d.err
+	}
+
+	n := copy(p, d.pending)
+	d.pending = d.pending[:copy(d.pending, d.pending[n:])]
+
+	return n, nil
+}
+
+// nextLatin1 reads a latin-1 character, they're the first 256 code points
+func nextLatin1(src *bufio.Reader) (rune, error) {
+	b, err := src.ReadByte()
+	return rune(b), err
+}
+
+// nextUTF16 reads a utf-16 character in the given byte order, which is two bytes or a surrogate pair of four
+func nextUTF16(order binary.ByteOrder) func(src *bufio.Reader) (rune, error) {
+	return func(src *bufio.Reader) (rune, error) {
+		var b [2]byte
+		if _, err := io.ReadFull(src, b[:]); err == io.ErrUnexpectedEOF {
+			return utf8.RuneError, nil // an odd byte at the end, the next read gets io.EOF
+		} else if err != nil {
+			return 0, err
+		}
+		r := rune(order.Uint16(b[:]))
+		if !utf16.IsSurrogate(r) {
+			return r, nil
+		}
+
+		if low, err := src.Peek(2); err == nil {
+			if decoded := utf16.DecodeRune(r, rune(order.Uint16(low))); decoded != utf8.RuneError {
+				src.Discard(2)
+				return decoded, nil
+			}
+		}
+		return utf8.RuneError, nil
+	}
+}
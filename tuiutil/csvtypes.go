@@ -0,0 +1,191 @@
+package tuiutil
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mathaou/termdbms/database"
+)
+
+const (
+	DefaultSampleSize      = 1000  // rows of a csv file looked at to infer the column types
+	DefaultImportBatchSize = 10000 // rows of a csv file inserted per transaction
+)
+
+// types inferred for csv columns
+const (
+	TypeInteger  = "INTEGER"
+	TypeReal     = "REAL"
+	TypeText     = "TEXT"
+	TypeDate     = "DATE"
+	TypeTime     = "TIME"
+	TypeDateTime = "DATETIME"
+)
+
+// CSVOptions are the settings for reading a csv file into a table
+type CSVOptions struct {
+	KeepOriginalColumns bool                             // column names as they are in the header, otherwise cleaned up by cleanHeader
+	Types               map[string]string                // declared types by column name, instead of the inferred ones
+	Delimiter           rune                             // between fields, a comma when not set
+	Comment             rune                             // lines starting with it are skipped, none when not set
+	LazyQuotes          bool                             // quotes can appear in unquoted fields and unescaped in quoted ones
+	Encoding            string                           // one of the Encoding* constants, from the byte order mark when empty
+	NoHeader            bool                             // the first line is data, columns are named col1..colN
+	SampleSize          int                              // rows looked at to infer column types
+	BatchSize           int                              // rows inserted per transaction
+	Progress            func(rows int, read, size int64) // called after every batch with the rows so far and how much of the file was read
+}
+
+// ParseColumnTypes reads column types given like "id=INTEGER,price=REAL"
+func ParseColumnTypes(s string) (map[string]string, error) {
+	types := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return types, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("%q isn't a column type, expected column=TYPE", pair)
+		}
+		types[strings.TrimSpace(parts[0])] = strings.ToUpper(strings.TrimSpace(parts[1]))
+	}
+
+	return types, nil
+}
+
+// IsCSVNull is whether a csv value can stand for NULL, only empty ones can. Whether it does depends on the column,
+// see ConvertCSVValue. The text NULL is never taken for one
+func IsCSVNull(value string) bool {
+	return value == ""
+}
+
+// isCSVInteger is whether a value is an integer that's fine to store as one. Leading zeros (zip codes, ids) mean it's
+// text, as do integers too big for 64 bits
+func isCSVInteger(value string) bool {
+	digits := strings.TrimLeft(value, "+-")
+	if len(digits) > 1 && digits[0] == '0' {
+		return false
+	}
+	_, err := strconv.ParseInt(value, 10, 64)
+
+	return err == nil
+}
+
+// isCSVReal is whether a value is a plain decimal number, ParseFloat also takes things like NaN, Inf and hex
+func isCSVReal(value string) bool {
+	if strings.ContainsAny(value, "nNiIxXpP_") {
+		return false
+	}
+	digits := strings.TrimLeft(value, "+-")
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' {
+		return false
+	}
+	_, err := strconv.ParseFloat(value, 64)
+
+	return err == nil
+}
+
+// parseCSVTime reads a date/time value in one of database.TimeLayouts, giving it back the way sqlite stores them
+// so that they sort and compare correctly
+func parseCSVTime(value string) (string, error) {
+	v, err := database.ParseValue(&database.SQLite{}, TypeDateTime, value)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", errors.New("not a date")
+	}
+
+	return s, nil
+}
+
+// getTimeType is which of the date/time types a value parseCSVTime gave back is
+func getTimeType(normalized string) string {
+	switch {
+	case !strings.Contains(normalized, ":"):
+		return TypeDate
+	case !strings.Contains(normalized, "-") || strings.Index(normalized, ":") < strings.Index(normalized, "-"):
+		return TypeTime
+	}
+
+	return TypeDateTime
+}
+
+// InferColumnType works out the type of a csv column from a sample of its values. Empty values don't count, a column
+// with nothing else in it is TEXT
+func InferColumnType(values []string) string {
+	integer, decimal, times := true, true, true
+	timeType := ""
+	count := 0
+	for _, v := range values {
+		if IsCSVNull(v) {
+			continue
+		}
+		count++
+		integer = integer && isCSVInteger(v)
+		decimal = decimal && isCSVReal(v)
+		if times {
+			normalized, err := parseCSVTime(v)
+			switch t := getTimeType(normalized); {
+			case err != nil:
+				times = false
+			case timeType == "" || timeType == t:
+				timeType = t
+			case timeType != TypeTime && t != TypeTime: // dates with and without a time of day
+				timeType = TypeDateTime
+			default:
+				times = false
+			}
+		}
+		if !integer && !decimal && !times {
+			return TypeText
+		}
+	}
+
+	switch {
+	case count == 0:
+		return TypeText
+	case integer:
+		return TypeInteger
+	case decimal:
+		return TypeReal
+	case times && timeType != "":
+		return timeType
+	}
+
+	return TypeText
+}
+
+// ConvertCSVValue turns a csv value into what goes into a column of the given declared type. Values that don't fit
+// the type are kept as text, which sqlite allows. Empty values are NULL, except in text columns where they're
+// empty strings
+func ConvertCSVValue(value, declared string) interface{} {
+	kind := database.GetColumnKind(declared)
+	if IsCSVNull(value) {
+		if kind == database.KindText || kind == database.KindAny {
+			return value
+		}
+		return nil
+	}
+
+	switch kind {
+	case database.KindInteger:
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil && isCSVInteger(value) {
+			return i
+		}
+	case database.KindReal, database.KindNumeric:
+		if f, err := strconv.ParseFloat(value, 64); err == nil && isCSVReal(value) {
+			return f
+		}
+	case database.KindTime:
+		if t, err := parseCSVTime(value); err == nil {
+			return t
+		}
+	}
+
+	return value
+}
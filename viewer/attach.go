@@ -0,0 +1,75 @@
+package viewer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mathaou/termdbms/database"
+)
+
+// AttachDatabase handles :attach <file> as <alias>, the tables of the file get listed after the others as alias.table
+// and can be viewed and queried but not changed
+func AttachDatabase(m *TuiModel, args string) {
+	ExitToDefaultView(m)
+
+	sqlite, ok := m.DefaultTable.Database.(*database.SQLite)
+	if !ok {
+		m.WriteMessage("Only SQLite databases can attach other files")
+		return
+	}
+
+	args = " " + strings.TrimSpace(args)
+	i := strings.LastIndex(strings.ToLower(args), " as ")
+	if i < 0 {
+		m.WriteMessage("Use :attach <file> as <alias>")
+		return
+	}
+	file, alias := strings.TrimSpace(args[:i]), strings.TrimSpace(args[i+len(" as "):])
+	if file == "" || alias == "" {
+		m.WriteMessage("Use :attach <file> as <alias>")
+		return
+	}
+	if exists, _ := Exists(file); !exists { // sqlite would happily create an empty database instead
+		m.WriteMessage(fmt.Sprintf("%s doesn't exist", file))
+		return
+	}
+	if abs, err := filepath.Abs(file); err == nil { // the working directory isn't what the database thinks it is
+		file = abs
+	}
+
+	if err := sqlite.Attach(file, alias); err != nil {
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+	if err := m.SetModel(nil, sqlite.GetDatabaseReference()); err != nil {
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+
+	// show the first table of the new database
+	for index := 1; index <= len(m.DefaultData.TableIndexMap); index++ {
+		if strings.HasPrefix(m.DefaultData.TableIndexMap[index], alias+".") {
+			m.UI.CurrentTable = index
+			break
+		}
+	}
+	m.WriteMessage(fmt.Sprintf("Attached %s as %s, read-only", filepath.Base(file), alias))
+}
+
+// GetKindLabel is shown next to the schema name for anything that isn't a regular table of the main database
+func GetKindLabel(kind string, attached bool) string {
+	if attached {
+		return " [attached, read-only]"
+	}
+	switch kind {
+	case database.TableKindView:
+		return " [view, read-only]"
+	case database.TableKindVirtual:
+		return " [virtual]"
+	case database.TableKindShadow:
+		return " [virtual table data]"
+	}
+
+	return ""
+}
@@ -0,0 +1,184 @@
+package viewer
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mathaou/termdbms/database"
+)
+
+const (
+	BlobDumpLimit = 64 * 1024 // bytes of a blob shown in selection view, [P] writes all of it to a file
+)
+
+// GetBlobSummary is what a blob looks like in a cell, its size and what it seems to be
+func GetBlobSummary(b []byte) string {
+	return fmt.Sprintf("BLOB %s %s", FormatByteSize(len(b)), GetBlobKind(b))
+}
+
+// GetBlobKind guesses what's in a blob: text, some kind of file like an image, or just binary
+func GetBlobKind(b []byte) string {
+	if len(b) == 0 {
+		return "empty"
+	}
+	if IsBlobText(b) {
+		return "text"
+	}
+
+	kind := strings.SplitN(http.DetectContentType(b), ";", 2)[0]
+	if kind == "application/octet-stream" {
+		return "binary"
+	}
+
+	return kind
+}
+
+// IsBlobText is whether a blob is UTF-8 text that's fine to show as is
+func IsBlobText(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FormatByteSize writes a number of bytes the way people read them
+func FormatByteSize(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	size := float64(n) / unit
+	for _, suffix := range []string{"KiB", "MiB", "GiB"} {
+		if size < unit || suffix == "GiB" {
+			return fmt.Sprintf("%.1f %s", size, suffix)
+		}
+		size /= unit
+	}
+
+	return ""
+}
+
+// GetBlobView is what selection view shows for a blob: what it is, its text if it's text, and a hex dump of it
+func GetBlobView(b []byte) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%s, %d bytes. [P] to write it to a file\n\n", GetBlobSummary(b), len(b)))
+
+	if IsBlobText(b) && len(b) > 0 {
+		builder.WriteString("TEXT\n")
+		builder.WriteString(string(b))
+		builder.WriteString("\n\nHEX\n")
+	}
+
+	dump := b
+	if len(dump) > BlobDumpLimit {
+		dump = dump[:BlobDumpLimit]
+	}
+	builder.WriteString(hex.Dump(dump))
+	if len(b) > len(dump) {
+		builder.WriteString(fmt.Sprintf("... %d more bytes\n", len(b)-len(dump)))
+	}
+
+	return builder.String()
+}
+
+// GetBlobLiteral is a blob written out the way edits read them back in, as x'hex'
+func GetBlobLiteral(b []byte) string {
+	return "x'" + hex.EncodeToString(b) + "'"
+}
+
+// WriteBlobFile writes a blob as is to a new file in the working directory
+func WriteBlobFile(m *TuiModel, b []byte) (string, error) {
+	rand.Seed(time.Now().Unix())
+	fileName := m.GetSchemaName() + "_" + "blob_" + fmt.Sprintf("%d", rand.Int()) + ".bin"
+	e := os.WriteFile(fileName, b, 0777)
+	return fileName, e
+}
+
+// ExportBlob handles :blob export [file], writing the selected cell to a file byte for byte
+func ExportBlob(m *TuiModel, value interface{}, fileName string) {
+	ExitToDefaultView(m)
+
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		m.WriteMessage("Only BLOB and text cells can be written to a file")
+		return
+	}
+
+	var err error
+	if fileName == "" {
+		fileName, err = WriteBlobFile(m, b)
+	} else {
+		err = os.WriteFile(fileName, b, 0777)
+	}
+	if err != nil {
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+
+	m.WriteMessage(fmt.Sprintf("Wrote %s to %s", FormatByteSize(len(b)), fileName))
+}
+
+// ImportBlob handles :blob import <file>, replacing the selected cell with what's in the file. Text columns
+// get the file as text, as long as it is text
+func ImportBlob(m *TuiModel, original *interface{}, fileName string) {
+	if original == nil {
+		return
+	}
+	if fileName == "" {
+		m.WriteMessage("Use :blob import <file>")
+		return
+	}
+
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		ExitToDefaultView(m)
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+
+	var value interface{} = b
+	column := m.GetSelectedColumnName()
+	switch database.GetColumnKind(m.GetSelectedColumnType()) {
+	case database.KindAny, database.KindBlob:
+	case database.KindText:
+		if !utf8.Valid(b) {
+			m.WriteMessage(fmt.Sprintf("%s isn't text, so it can't go in %s", fileName, column))
+			return
+		}
+		value = string(b)
+	default:
+		m.WriteMessage(fmt.Sprintf("%s can't hold the contents of a file", column))
+		return
+	}
+
+	key, err := m.GetRowKey()
+	if err != nil {
+		ExitToDefaultView(m)
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+
+	setCellValue(m, key, value, original)
+	m.TextInput.Model.SetValue("")
+	m.WriteMessage(fmt.Sprintf("Read %s from %s into %s", FormatByteSize(len(b)), fileName, column))
+}
@@ -1,6 +1,7 @@
 package viewer
 
 import (
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -32,19 +33,38 @@ type UIState struct {
 	FormatModeEnabled bool
 	BorderToggle      bool
 	SQLEdit           bool
+	InsertEdit        bool // :insert form
+	FilterEdit        bool // the header is the / filter bar
+	EditTouched       bool // the text of the cell being edited was typed into, untouched cells aren't saved
 	ShowClipboard     bool
+	ShowFind          bool // :find results
 	ExpandColumn      int
 	CurrentTable      int
+	SelectedRows      map[int]map[string]interface{} // row identity values of rows marked for multi-row operations, by row
+	SelectedBlob      []byte                         // the BLOB selection view is showing, [P] writes it out as is
 }
 
 type UIData struct {
 	TableHeaders      map[string][]string // keeps track of which schema has which headers
 	TableHeadersSlice []string
 	TableSlices       map[string][]interface{}
-	TableIndexMap     map[int]string // keeps the schemas in order
+	TableIndexMap     map[int]string                      // keeps the schemas in order
+	TableKeys         map[string]*database.RowIdentity    // how a single row of each schema is addressed, nil if it can't be
+	TableKinds        map[string]string                   // database.TableKind of each schema, empty for query results
+	TableWindows      map[string]*TableWindow             // which rows of each schema are loaded, nil for query results
+	TableSorts        map[string]ColumnSort               // how each schema is ordered
+	TableFilters      map[string]RowFilter                // which rows of each schema are shown
+	OriginalData      map[string]map[string][]interface{} // in memory schemas before sorting/filtering, by schema
 	EditTextBuffer    string
 }
 
+// TableWindow keeps track of the rows of a table that are in memory, tables get paged in as they're scrolled through
+type TableWindow struct {
+	Offset int  // index of the first loaded row within the whole table
+	Total  int  // number of rows in the whole table
+	Loaded bool // false until the table is first viewed, or after it was changed underneath us
+}
+
 type FormatState struct {
 	EditSlices     []*string // the bit to show
 	Text           []string  // the master collection of lines to edit
@@ -67,10 +87,16 @@ type TuiModel struct {
 	Viewport        viewport.Model
 	ClipboardList   list.Model
 	Clipboard       []list.Item
+	FindList        list.Model
 	TableStyle      lipgloss.Style
 	MouseData       tea.MouseEvent
 	TextInput       LineEdit
 	FormatInput     LineEdit
+	InsertForm      InsertState
+	Confirm         *Confirmation // pending y/n prompt, if any
+	Query           *RunningQuery // :sql statement running in the background, if any
+	Spinner         spinner.Model
+	NavigationStack []CursorPosition // where followed foreign keys were followed from, most recent last
 	UndoStack       []TableState
 	RedoStack       []TableState
 }
@@ -0,0 +1,113 @@
+package viewer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mathaou/termdbms/database"
+)
+
+// Confirmation is a y/n prompt shown in the header bar. Action runs on y, anything else cancels.
+type Confirmation struct {
+	Prompt string
+	Action func(m *TuiModel)
+}
+
+// HandleConfirmation answers the pending confirmation with the pressed key
+func HandleConfirmation(m *TuiModel, str string) {
+	confirm := m.Confirm
+	m.Confirm = nil
+	if str == "y" || str == "Y" {
+		confirm.Action(m)
+	}
+}
+
+// ToggleRowSelection marks/unmarks the row under the cursor for multi-row operations like delete
+func ToggleRowSelection(m *TuiModel) {
+	row := m.GetAbsoluteRow()
+	if row >= m.GetRowCount() {
+		return
+	}
+
+	if _, ok := m.UI.SelectedRows[row]; ok {
+		delete(m.UI.SelectedRows, row)
+		return
+	}
+
+	// keep the key around, the row might not be loaded anymore by the time it's used
+	key, err := m.GetRowKeyAt(row)
+	if err != nil {
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+	if m.UI.SelectedRows == nil {
+		m.UI.SelectedRows = make(map[int]map[string]interface{})
+	}
+	m.UI.SelectedRows[row] = key
+}
+
+// PromptDelete asks to delete the selected rows, or the row under the cursor when nothing is selected
+func PromptDelete(m *TuiModel) {
+	if m.QueryData != nil || m.QueryResult != nil {
+		m.WriteMessage("Cannot manipulate database through UI while query results are being displayed.")
+		return
+	}
+
+	var rows []int
+	for row := range m.UI.SelectedRows {
+		rows = append(rows, row)
+	}
+	sort.Ints(rows)
+
+	var keys []map[string]interface{}
+	for _, row := range rows {
+		keys = append(keys, m.UI.SelectedRows[row])
+	}
+	if len(rows) == 0 {
+		row := m.GetAbsoluteRow()
+		if row >= m.GetRowCount() {
+			return
+		}
+		key, err := m.GetRowKeyAt(row)
+		if err != nil {
+			m.DisplayMessage(fmt.Sprintf("%v", err))
+			return
+		}
+		rows = append(rows, row)
+		keys = append(keys, key)
+	}
+
+	schemaName := m.GetSchemaName()
+	m.Confirm = &Confirmation{
+		Prompt: fmt.Sprintf(" Delete %d row(s) from %s? (y/n)", len(keys), schemaName),
+		Action: func(m *TuiModel) {
+			deleteRows(m, schemaName, keys, rows[0])
+		},
+	}
+}
+
+// deleteRows deletes the rows with the given keys all at once, if one of them can't be deleted none of them are
+func deleteRows(m *TuiModel, schemaName string, keys []map[string]interface{}, firstRow int) {
+	old, n := populateUndo(m)
+	deleted, err := database.DeleteRows(m.DefaultTable.Database, schemaName, keys)
+	if err != nil || deleted == 0 { // nothing changed, so there's nothing to undo
+		discardUndo(m, old, n)
+	}
+
+	m.UI.SelectedRows = nil
+	if err != nil {
+		m.DisplayMessage(fmt.Sprintf("No rows were deleted: %v", err))
+		return
+	}
+	if deleted > 0 {
+		if err = m.LoadTable(m.DefaultTable.Database.GetDatabaseReference(), schemaName, m.UI.CurrentTable); err != nil {
+			m.DisplayMessage(fmt.Sprintf("%v", err))
+			return
+		}
+		if total := m.GetRowCount(); total > 0 {
+			m.MoveCursorToRow(Min(firstRow, total-1))
+		}
+	}
+
+	m.WriteMessage(fmt.Sprintf("Deleted %d row(s) from %s", deleted, schemaName))
+}
@@ -0,0 +1,390 @@
+package viewer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mathaou/termdbms/database"
+)
+
+const (
+	DefaultBatchSize = 100 // rows per INSERT in SQL dumps
+)
+
+// SQLWriter writes rows as INSERT statements, BatchSize rows to a statement
+type SQLWriter struct {
+	writer    io.Writer
+	driver    string
+	table     string
+	kinds     map[string]database.ColumnKind // how values are written, by column
+	batchSize int
+	headers   []string
+	batch     []string
+}
+
+func NewSQLWriter(w io.Writer, driver, table string, kinds map[string]database.ColumnKind, batchSize int) *SQLWriter {
+	if batchSize < 1 {
+		batchSize = DefaultBatchSize
+	}
+
+	return &SQLWriter{
+		writer:    w,
+		driver:    driver,
+		table:     table,
+		kinds:     kinds,
+		batchSize: batchSize,
+	}
+}
+
+func (s *SQLWriter) WriteHeader(headers []string) error {
+	s.headers = headers
+	return nil
+}
+
+func (s *SQLWriter) WriteRow(row []interface{}) error {
+	values := make([]string, len(row))
+	for i, v := range row {
+		kind := database.KindAny
+		if i < len(s.headers) {
+			kind = s.kinds[s.headers[i]]
+		}
+		values[i] = database.FormatLiteral(s.driver, kind, v)
+	}
+	s.batch = append(s.batch, "("+strings.Join(values, ", ")+")")
+	if len(s.batch) >= s.batchSize {
+		return s.Flush()
+	}
+
+	return nil
+}
+
+func (s *SQLWriter) Flush() error {
+	if len(s.batch) == 0 {
+		return nil
+	}
+
+	var columns []string
+	for _, h := range s.headers {
+		columns = append(columns, database.QuoteIdentifierForDriver(s.driver, h))
+	}
+	statement := fmt.Sprintf("INSERT INTO %s (%s) VALUES\n%s;\n", database.QuoteTableNameForDriver(s.driver, s.table),
+		strings.Join(columns, ", "), strings.Join(s.batch, ",\n"))
+	s.batch = s.batch[:0]
+	_, err := io.WriteString(s.writer, statement)
+
+	return err
+}
+
+// ParseDialect checks a dialect given for a dump, empty being the dialect of d
+func ParseDialect(d database.Database, dialect string) (string, error) {
+	switch strings.ToLower(dialect) {
+	case "":
+		return database.GetDriver(d), nil
+	case database.DriverSQLite, database.DriverMySQL, database.DriverPostgres:
+		return strings.ToLower(dialect), nil
+	}
+
+	return "", fmt.Errorf("%s isn't a dialect, use %s, %s or %s", dialect, database.DriverSQLite, database.DriverMySQL, database.DriverPostgres)
+}
+
+// writeDumpStart writes what goes before the tables of a dump, so it loads in one go without tripping over foreign keys
+func writeDumpStart(w io.Writer, driver string) error {
+	header := fmt.Sprintf("-- SQL dump for %s, written by termdbms on %s\n", driver, time.Now().Format("2006-01-02 15:04:05"))
+	switch driver {
+	case database.DriverSQLite:
+		header += "PRAGMA foreign_keys=OFF;\nBEGIN TRANSACTION;\n"
+	case database.DriverMySQL:
+		header += "SET FOREIGN_KEY_CHECKS=0;\nSTART TRANSACTION;\n"
+	default:
+		header += "BEGIN;\n"
+	}
+	_, err := io.WriteString(w, header)
+
+	return err
+}
+
+func writeDumpEnd(w io.Writer, driver string) error {
+	footer := "\nCOMMIT;\n"
+	if driver == database.DriverMySQL {
+		footer += "SET FOREIGN_KEY_CHECKS=1;\n"
+	}
+	_, err := io.WriteString(w, footer)
+
+	return err
+}
+
+// writeCreateSchemas creates the schemas tables are in before any of them, once each
+func writeCreateSchemas(w io.Writer, d database.Database, driver string, tables []string) error {
+	written := make(map[string]bool)
+	for _, table := range tables {
+		schema := database.GetDumpSchema(d, driver, table)
+		if schema == "" || written[schema] {
+			continue
+		}
+		written[schema] = true
+		if _, err := io.WriteString(w, "\n"+database.GenerateCreateSchema(driver, schema)+";\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getColumnKinds gets the kind of every column of a table, by name
+func getColumnKinds(columns []database.ColumnInfo) map[string]database.ColumnKind {
+	kinds := make(map[string]database.ColumnKind)
+	for _, c := range columns {
+		kinds[c.Name] = database.GetColumnKind(c.Type)
+	}
+
+	return kinds
+}
+
+// ExportSQL handles :export sql, dumping the current table, the query results or with -all every table in the database
+func ExportSQL(m *TuiModel, w io.Writer, options ExportOptions) (int, error) {
+	d := m.Table().Database
+	driver, err := ParseDialect(d, options.Dialect)
+	if err != nil {
+		return 0, err
+	}
+	if options.All {
+		return DumpDatabase(d, w, nil, driver, options.BatchSize)
+	}
+
+	schemaName := m.GetSchemaName()
+	headers := m.Data().TableHeaders[schemaName]
+	var (
+		create  string
+		indexes []string
+		kinds   map[string]database.ColumnKind
+		name    = schemaName
+	)
+	if m.GetTableWindow() == nil { // query results have no schema, column types are guessed from the values
+		var columns []database.ColumnInfo
+		for _, h := range headers {
+			columns = append(columns, database.ColumnInfo{
+				Name: h,
+				Type: GetTypeNameOfValues(m.GetSchemaData()[h]),
+			})
+		}
+		create = database.GenerateCreateTable(driver, name, columns, nil)
+		kinds = getColumnKinds(columns)
+	} else {
+		name = database.GetDumpName(d, driver, schemaName)
+		if create, indexes, err = database.GetCreateStatements(d, driver, schemaName); err != nil {
+			return 0, err
+		}
+		columns, err := d.GetColumns(schemaName)
+		if err != nil {
+			return 0, err
+		}
+		kinds = getColumnKinds(columns)
+	}
+
+	if err = writeDumpStart(w, driver); err != nil {
+		return 0, err
+	}
+	if m.GetTableWindow() != nil {
+		if err = writeCreateSchemas(w, d, driver, []string{schemaName}); err != nil {
+			return 0, err
+		}
+	}
+	if _, err = io.WriteString(w, "\n"+create+";\n\n"); err != nil {
+		return 0, err
+	}
+	writer := NewSQLWriter(w, driver, name, kinds, options.BatchSize)
+	count, err := WriteRows(m, writer)
+	if err == nil {
+		err = writer.Flush()
+	}
+	for _, statement := range indexes {
+		if err == nil {
+			_, err = io.WriteString(w, statement+";\n")
+		}
+	}
+	if err != nil {
+		return count, err
+	}
+
+	return count, writeDumpEnd(w, driver)
+}
+
+// GetTypeNameOfValues guesses a column type from the values in it, for query results that don't have one
+func GetTypeNameOfValues(values []interface{}) string {
+	for _, v := range values {
+		switch v.(type) {
+		case nil:
+			continue
+		case int64, int32, int:
+			return "INTEGER"
+		case float64, float32:
+			return "REAL"
+		case bool:
+			return "BOOLEAN"
+		case []byte:
+			return "BLOB"
+		case time.Time:
+			return "TIMESTAMP"
+		}
+		return "TEXT"
+	}
+
+	return "TEXT"
+}
+
+// DumpDatabase writes the tables of a database as SQL for driver, every table when tables is empty. Views are left
+// out, as are attached databases and the tables virtual tables keep their data in. Tables come after the ones they
+// reference so the dump loads in order
+func DumpDatabase(d database.Database, w io.Writer, tables []string, driver string, batchSize int) (int, error) {
+	if len(tables) == 0 {
+		rows, err := d.GetDatabaseReference().Query(d.GetTableNamesQuery())
+		if err != nil {
+			return 0, err
+		}
+		for rows.Next() {
+			var name, kind string
+			rows.Scan(&name, &kind)
+			if s, ok := d.(*database.SQLite); ok && s.IsAttachedTable(name) {
+				continue
+			}
+			if kind == database.TableKindTable || kind == database.TableKindVirtual && driver == database.DriverSQLite {
+				tables = append(tables, name)
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := writeDumpStart(w, driver); err != nil {
+		return 0, err
+	}
+	tables = OrderByForeignKeys(d, tables)
+	if err := writeCreateSchemas(w, d, driver, tables); err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, table := range tables {
+		count, err := dumpTable(d, w, table, driver, batchSize)
+		total += count
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, writeDumpEnd(w, driver)
+}
+
+// dumpTable writes the statements creating a table and every one of its rows
+func dumpTable(d database.Database, w io.Writer, table, driver string, batchSize int) (int, error) {
+	create, indexes, err := database.GetCreateStatements(d, driver, table)
+	if err != nil {
+		return 0, err
+	}
+	columns, err := d.GetColumns(table)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = io.WriteString(w, "\n"+create+";\n\n"); err != nil {
+		return 0, err
+	}
+
+	var (
+		headers []string
+		quoted  []string
+	)
+	for _, c := range columns {
+		headers = append(headers, c.Name)
+		quoted = append(quoted, d.QuoteIdentifier(c.Name))
+	}
+	identity, _ := d.GetRowIdentity(table)
+	rows, err := d.GetDatabaseReference().Query(fmt.Sprintf("select %s from %s%s", strings.Join(quoted, ", "),
+		d.QuoteTableName(table), GetOrderBy(d, identity, ColumnSort{})))
+	if err != nil {
+		return 0, err
+	}
+
+	writer := NewSQLWriter(w, driver, database.GetDumpName(d, driver, table), getColumnKinds(columns), batchSize)
+	writer.WriteHeader(headers)
+	count, err := WriteResultRows(rows, writer)
+	rows.Close()
+	if err == nil {
+		err = writer.Flush()
+	}
+	for _, statement := range indexes {
+		if err == nil {
+			_, err = io.WriteString(w, statement+";\n")
+		}
+	}
+
+	return count, err
+}
+
+// OrderByForeignKeys sorts tables so the ones referenced by foreign keys come before the tables referencing them.
+// Tables that reference each other stay in the order they were in
+func OrderByForeignKeys(d database.Database, tables []string) []string {
+	references := make(map[string][]string)
+	for _, table := range tables {
+		keys, _ := d.GetForeignKeys(table)
+		for _, fk := range keys {
+			references[table] = append(references[table], fk.Table)
+		}
+	}
+
+	var (
+		ordered []string
+		visited = make(map[string]bool)
+		visit   func(table string)
+	)
+	included := make(map[string]bool)
+	for _, table := range tables {
+		included[table] = true
+	}
+	visit = func(table string) {
+		if visited[table] || !included[table] {
+			return
+		}
+		visited[table] = true
+		for _, referenced := range references[table] {
+			visit(referenced)
+		}
+		ordered = append(ordered, table)
+	}
+	for _, table := range tables {
+		visit(table)
+	}
+
+	return ordered
+}
+
+// DumpToFile writes a SQL dump of the database, or of one table, to fileName. - writes to stdout
+func DumpToFile(d database.Database, fileName, table, dialect string) (int, error) {
+	driver, err := ParseDialect(d, dialect)
+	if err != nil {
+		return 0, err
+	}
+
+	var tables []string
+	if table != "" {
+		tables = append(tables, table)
+	}
+	if fileName == "-" {
+		return DumpDatabase(d, os.Stdout, tables, driver, DefaultBatchSize)
+	}
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		return 0, err
+	}
+	count, err := DumpDatabase(d, f, tables, driver, DefaultBatchSize)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return count, err
+}
@@ -27,7 +27,12 @@ func HandleMouseEvents(m *TuiModel, msg *tea.MouseMsg) {
 		}
 		break
 	case tea.MouseLeft:
-		if !m.UI.EditModeEnabled && !m.UI.FormatModeEnabled && m.GetRow() < len(m.GetColumnData()) {
+		if msg.Y == HeaderHeight-1 && !m.UI.RenderSelection && !m.UI.EditModeEnabled && !m.UI.FormatModeEnabled { // column headers
+			m.MouseData.X = msg.X
+			CycleSort(m)
+			break
+		}
+		if !m.UI.EditModeEnabled && !m.UI.FormatModeEnabled && m.GetAbsoluteRow() < m.GetRowCount() {
 			SelectOption(m)
 		}
 		break
@@ -117,7 +122,11 @@ func HandleKeyboardEvents(m *TuiModel, msg *tea.KeyMsg) tea.Cmd {
 	str := msg.String()
 
 	if m.UI.EditModeEnabled { // handle edit mode
+		before := m.TextInput.Model.Value()
 		HandleEditMode(m, str)
+		if str != "enter" && m.TextInput.Model.Value() != before {
+			m.UI.EditTouched = true
+		}
 		return nil
 	} else if m.UI.FormatModeEnabled {
 		if str == "esc" { // cycle focus
@@ -134,7 +143,11 @@ func HandleKeyboardEvents(m *TuiModel, msg *tea.KeyMsg) tea.Cmd {
 		if m.TextInput.Model.Focused() {
 			HandleEditMode(m, str)
 		} else {
+			before := m.Data().EditTextBuffer
 			HandleFormatMode(m, str)
+			if m.Data().EditTextBuffer != before {
+				m.UI.EditTouched = true
+			}
 		}
 
 		return nil
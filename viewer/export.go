@@ -0,0 +1,463 @@
+package viewer
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mathaou/termdbms/database"
+)
+
+// ExportOptions are the settings of an export, set with flags after :export <format>
+type ExportOptions struct {
+	Delimiter rune   // between the values of a csv row
+	Header    bool   // whether the column names are written first
+	Null      string // how NULL is written in formats that have no null of their own
+	Dialect   string // which database a SQL dump is for, the one being viewed when empty
+	BatchSize int    // rows per INSERT in a SQL dump
+	All       bool   // dump every table, not just the current one
+	Truncate  int    // longest a cell can be in tables for reading, 0 to never cut anything off
+	Title     string // what's being exported, set by Export
+}
+
+// RowWriter writes rows out in some format, one at a time so tables never have to be in memory all at once
+type RowWriter interface {
+	WriteHeader(headers []string) error
+	WriteRow(row []interface{}) error
+	Flush() error
+}
+
+// ExportFormat is a format :export can write
+type ExportFormat struct {
+	Extension string
+	Defaults  ExportOptions
+	NewWriter func(w io.Writer, options ExportOptions) RowWriter
+	Write     func(m *TuiModel, w io.Writer, options ExportOptions) (int, error) // instead of NewWriter, for more than rows
+}
+
+var (
+	// ExportFormats are the formats :export knows, by the name that's typed after it
+	ExportFormats = map[string]ExportFormat{
+		"csv": {
+			Extension: "csv",
+			Defaults: ExportOptions{
+				Delimiter: ',',
+				Header:    true,
+			},
+			NewWriter: NewCSVWriter,
+		},
+		"json": {
+			Extension: "json",
+			NewWriter: NewJSONWriter,
+		},
+		"ndjson": {
+			Extension: "ndjson",
+			NewWriter: NewNDJSONWriter,
+		},
+		"sql": {
+			Extension: "sql",
+			Defaults: ExportOptions{
+				BatchSize: DefaultBatchSize,
+			},
+			Write: ExportSQL,
+		},
+		"md": {
+			Extension: "md",
+			Defaults: ExportOptions{
+				Null: "NULL",
+			},
+			NewWriter: NewMarkdownWriter,
+		},
+		"html": {
+			Extension: "html",
+			Defaults: ExportOptions{
+				Null: "NULL",
+			},
+			NewWriter: NewHTMLWriter,
+		},
+	}
+)
+
+// ParseDelimiter reads a delimiter the way it's typed on the command line, a single character or its name (tab, comma, semicolon, pipe)
+func ParseDelimiter(s string) (rune, error) {
+	switch strings.ToLower(s) {
+	case "tab", `\t`:
+		return '\t', nil
+	case "comma":
+		return ',', nil
+	case "semicolon":
+		return ';', nil
+	case "pipe":
+		return '|', nil
+	}
+
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 || size != len(s) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, fmt.Errorf("%q can't be used as a delimiter, it has to be a single character, tab, comma, semicolon or pipe", s)
+	}
+
+	return r, nil
+}
+
+// ParseExportArgs reads what was typed after :export, a format, its flags and then the file to write
+func ParseExportArgs(args string) (ExportFormat, ExportOptions, string, error) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return ExportFormat{}, ExportOptions{}, "", errors.New("Use :export <format> [flags] [file], see :h for the formats")
+	}
+
+	format, ok := ExportFormats[strings.ToLower(fields[0])]
+	if !ok {
+		return ExportFormat{}, ExportOptions{}, "", fmt.Errorf("%s isn't a format that can be exported to", fields[0])
+	}
+
+	options := format.Defaults
+	flags := flag.NewFlagSet(":export", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	delimiter := flags.String("d", string(options.Delimiter), "")
+	flags.BoolVar(&options.Header, "header", options.Header, "")
+	flags.StringVar(&options.Null, "null", options.Null, "")
+	flags.StringVar(&options.Dialect, "dialect", options.Dialect, "")
+	flags.IntVar(&options.BatchSize, "batch", options.BatchSize, "")
+	flags.BoolVar(&options.All, "all", options.All, "")
+	flags.IntVar(&options.Truncate, "truncate", options.Truncate, "")
+	if err := flags.Parse(fields[1:]); err != nil {
+		return ExportFormat{}, ExportOptions{}, "", err
+	}
+	for _, arg := range flags.Args() {
+		name := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+		if strings.HasPrefix(arg, "-") && flags.Lookup(name) != nil {
+			return ExportFormat{}, ExportOptions{}, "", errors.New("flags go before the file name")
+		}
+	}
+	fileName := skipFields(args, len(fields)-flags.NArg()) // the file name is everything after the flags, spaces and all
+
+	if *delimiter != string(options.Delimiter) {
+		var err error
+		if options.Delimiter, err = ParseDelimiter(*delimiter); err != nil {
+			return ExportFormat{}, ExportOptions{}, "", err
+		}
+	}
+
+	return format, options, fileName, nil
+}
+
+// skipFields is what's left of s after its first n whitespace separated fields
+func skipFields(s string, n int) string {
+	s = strings.TrimSpace(s)
+	for i := 0; i < n && s != ""; i++ {
+		end := strings.IndexFunc(s, unicode.IsSpace)
+		if end < 0 {
+			return ""
+		}
+		s = strings.TrimLeftFunc(s[end:], unicode.IsSpace)
+	}
+
+	return s
+}
+
+// Export handles :export, writing the current table or query results to a file. Tables are read from the database
+// so all of their rows get written, filtered and sorted like they are on screen
+func Export(m *TuiModel, args string) {
+	ExitToDefaultView(m)
+	format, options, fileName, err := ParseExportArgs(args)
+	if err != nil {
+		m.WriteMessage(fmt.Sprintf("%v", err))
+		return
+	}
+
+	schemaName := m.GetSchemaName()
+	options.Title = schemaName
+	if fileName == "" {
+		rand.Seed(time.Now().UnixNano())
+		fileName = fmt.Sprintf("%s_%d.%s", strings.ReplaceAll(schemaName, ".", "_"), rand.Int(), format.Extension)
+	}
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+
+	var count int
+	if format.Write != nil {
+		count, err = format.Write(m, f, options)
+	} else {
+		w := format.NewWriter(f, options)
+		count, err = WriteRows(m, w)
+		if err == nil {
+			err = w.Flush()
+		}
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(fileName)
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+
+	if options.All {
+		schemaName = "every table"
+	}
+	m.WriteMessage(fmt.Sprintf("Exported %d row(s) of %s to %s", count, schemaName, fileName))
+}
+
+// WriteRows writes the headers and every row of the current table or query results to w, returning how many rows there were
+func WriteRows(m *TuiModel, w RowWriter) (int, error) {
+	schemaName := m.GetSchemaName()
+	headers := m.Data().TableHeaders[schemaName]
+	if err := w.WriteHeader(headers); err != nil {
+		return 0, err
+	}
+
+	if m.GetTableWindow() == nil { // query results are all in memory, already filtered and sorted
+		data := m.GetSchemaData()
+		count := len(m.GetColumnData())
+		for i := 0; i < count; i++ {
+			row := make([]interface{}, len(headers))
+			for j, h := range headers {
+				if i < len(data[h]) {
+					row[j] = data[h][i]
+				}
+			}
+			if err := w.WriteRow(row); err != nil {
+				return i, err
+			}
+		}
+		return count, nil
+	}
+
+	d := m.Table().Database
+	var columns []string
+	for _, h := range headers {
+		columns = append(columns, d.QuoteIdentifier(h))
+	}
+	where, values := m.GetTableCondition(schemaName, 1)
+	query := fmt.Sprintf("select %s from %s%s%s", strings.Join(columns, ", "), d.QuoteTableName(schemaName), where,
+		GetOrderBy(d, m.DefaultData.TableKeys[schemaName], m.DefaultData.TableSorts[schemaName]))
+
+	rows, err := d.GetDatabaseReference().Query(query, values...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	return WriteResultRows(rows, w)
+}
+
+// WriteResultRows writes every row of a result to w, without a header
+func WriteResultRows(rows *sql.Rows, w RowWriter) (int, error) {
+	columnTypes, _ := rows.ColumnTypes()
+	columnNames, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	row := make([]interface{}, len(columnNames))
+	pointers := make([]interface{}, len(columnNames))
+	for rows.Next() {
+		for i := range row {
+			row[i] = nil
+			pointers[i] = &row[i]
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return count, err
+		}
+		for i := range row {
+			var columnType *sql.ColumnType
+			if i < len(columnTypes) {
+				columnType = columnTypes[i]
+			}
+			row[i] = database.ConvertScannedValue(row[i], columnType)
+		}
+		if err = w.WriteRow(row); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, rows.Err()
+}
+
+// GetExportString is a value written out as text without losing anything, unlike the way it's shown in a cell. BLOBs that
+// aren't text are written as hex
+func GetExportString(val interface{}, null string) string {
+	switch v := val.(type) {
+	case nil:
+		return null
+	case []byte:
+		if IsBlobText(v) {
+			return string(v)
+		}
+		return hex.EncodeToString(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+
+	return GetStringRepresentationOfInterface(val)
+}
+
+// CSVWriter writes rows as RFC 4180 csv
+type CSVWriter struct {
+	writer  *csv.Writer
+	options ExportOptions
+}
+
+func NewCSVWriter(w io.Writer, options ExportOptions) RowWriter {
+	writer := csv.NewWriter(w)
+	writer.Comma = options.Delimiter
+
+	return &CSVWriter{
+		writer:  writer,
+		options: options,
+	}
+}
+
+func (c *CSVWriter) WriteHeader(headers []string) error {
+	if !c.options.Header {
+		return nil
+	}
+
+	return c.writer.Write(headers)
+}
+
+func (c *CSVWriter) WriteRow(row []interface{}) error {
+	record := make([]string, len(row))
+	for i, v := range row {
+		record[i] = GetExportString(v, c.options.Null)
+	}
+
+	return c.writer.Write(record)
+}
+
+func (c *CSVWriter) Flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// JSONWriter writes rows as objects keyed by column name, either in one array or one object per line (NDJSON).
+// Values keep their types, NULL is null and BLOBs are base64
+type JSONWriter struct {
+	writer    io.Writer
+	headers   []string
+	lines     bool // NDJSON
+	wroteRows bool
+}
+
+func NewJSONWriter(w io.Writer, options ExportOptions) RowWriter {
+	return &JSONWriter{
+		writer: w,
+	}
+}
+
+func NewNDJSONWriter(w io.Writer, options ExportOptions) RowWriter {
+	return &JSONWriter{
+		writer: w,
+		lines:  true,
+	}
+}
+
+func (j *JSONWriter) WriteHeader(headers []string) error {
+	j.headers = headers
+	return nil
+}
+
+func (j *JSONWriter) WriteRow(row []interface{}) error {
+	var object strings.Builder
+	object.WriteString("{")
+	for i, h := range j.headers {
+		if i >= len(row) {
+			break
+		}
+		if i > 0 {
+			object.WriteString(",")
+		}
+		key, _ := marshalJSON(h)
+		value, err := marshalJSON(GetJSONValue(row[i]))
+		if err != nil {
+			return err
+		}
+		object.Write(key)
+		object.WriteString(":")
+		object.Write(value)
+	}
+	object.WriteString("}")
+
+	prefix := "\n"
+	if !j.lines {
+		prefix = "[\n  "
+		if j.wroteRows {
+			prefix = ",\n  "
+		}
+	} else if !j.wroteRows {
+		prefix = ""
+	}
+	j.wroteRows = true
+	_, err := io.WriteString(j.writer, prefix+object.String())
+
+	return err
+}
+
+func (j *JSONWriter) Flush() error {
+	end := "\n"
+	if !j.lines && j.wroteRows {
+		end = "\n]\n"
+	} else if !j.lines {
+		end = "[]\n"
+	} else if !j.wroteRows {
+		end = ""
+	}
+	_, err := io.WriteString(j.writer, end)
+
+	return err
+}
+
+// GetJSONValue is a value the way it goes into JSON. Most already marshal to the right thing ([]byte to base64,
+// time.Time to RFC 3339), floats that JSON can't hold become strings
+func GetJSONValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return strconv.FormatFloat(float64(v), 'f', -1, 32)
+		}
+	}
+
+	return val
+}
+
+// marshalJSON is json.Marshal without escaping <, > and &, which only matters when the JSON goes into HTML
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,143 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mathaou/termdbms/database"
+)
+
+const (
+	filterPrompt = "/ "
+	editPrompt   = "> "
+	wherePrefix  = "where "
+)
+
+// RowFilter narrows down the rows of a schema, either to ones containing some text in any column or to ones matching a SQL condition
+type RowFilter struct {
+	Text  string
+	Where bool // Text is a SQL WHERE condition instead of text to search for
+}
+
+// ParseFilter reads what was typed in the filter bar, input starting with "where" is a SQL condition
+func ParseFilter(input string) RowFilter {
+	input = strings.TrimSpace(input)
+	if strings.HasPrefix(strings.ToLower(input), wherePrefix) {
+		return RowFilter{
+			Text:  strings.TrimSpace(input[len(wherePrefix):]),
+			Where: true,
+		}
+	}
+
+	return RowFilter{Text: input}
+}
+
+// String is the filter the way it'd be typed into the filter bar
+func (f RowFilter) String() string {
+	if f.Where {
+		return wherePrefix + f.Text
+	}
+
+	return f.Text
+}
+
+// Active is whether the filter narrows anything down
+func (f RowFilter) Active() bool {
+	return f.Text != ""
+}
+
+// GetCondition gets the filter as a condition for a WHERE clause, with placeholders numbered from position. Empty when inactive
+func (f RowFilter) GetCondition(d database.Database, columns []string, position int) (string, []interface{}) {
+	if !f.Active() {
+		return "", nil
+	}
+	if f.Where {
+		return "(" + f.Text + ")", nil
+	}
+
+	return database.TextSearchCondition(d, columns, f.Text, position)
+}
+
+// MatchesRow checks a row of in memory data against a text filter
+func (f RowFilter) MatchesRow(data map[string][]interface{}, columns []string, row int) bool {
+	if !f.Active() {
+		return true
+	}
+
+	text := strings.ToLower(f.Text)
+	for _, c := range columns {
+		if row < len(data[c]) && strings.Contains(strings.ToLower(GetStringRepresentationOfInterface(data[c][row])), text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetTableCondition gets the " where ..." clause and its values for the current filter on a table, empty when there isn't one
+func (m *TuiModel) GetTableCondition(schemaName string, position int) (string, []interface{}) {
+	condition, values := m.DefaultData.TableFilters[schemaName].GetCondition(m.DefaultTable.Database, m.DefaultData.TableHeaders[schemaName], position)
+	if condition == "" {
+		return "", nil
+	}
+
+	return " where " + condition, values
+}
+
+// OpenFilterBar puts the header into filter mode, filled in with the current filter so it can be changed
+func OpenFilterBar(m *TuiModel) {
+	m.UI.EditModeEnabled = true
+	m.UI.FilterEdit = true
+	m.TextInput.Model.Prompt = filterPrompt
+	value := m.Data().TableFilters[m.GetSchemaName()].String()
+	m.TextInput.Model.SetValue(value)
+	m.TextInput.Model.SetCursor(len(value))
+}
+
+// CloseFilterBar goes back to the table without changing the filter
+func CloseFilterBar(m *TuiModel) {
+	m.UI.EditModeEnabled = false
+	m.UI.FilterEdit = false
+	m.TextInput.Model.Prompt = editPrompt
+	m.TextInput.Model.SetValue("")
+}
+
+// ApplyFilter narrows the current schema down to the rows matching input, empty input shows every row again
+func ApplyFilter(m *TuiModel, input string) {
+	CloseFilterBar(m)
+
+	schemaName := m.GetSchemaName()
+	d := m.Data()
+	if d.TableFilters == nil {
+		d.TableFilters = make(map[string]RowFilter)
+	}
+	previous := d.TableFilters[schemaName]
+	filter := ParseFilter(input)
+	m.UI.SelectedRows = nil
+
+	if window := m.GetTableWindow(); window == nil { // query results are all in memory already
+		if filter.Where {
+			m.WriteMessage("SQL filters only work on tables, use :sql to query the results again")
+			return
+		}
+		d.TableFilters[schemaName] = filter
+		ArrangeInMemory(m, schemaName)
+	} else {
+		d.TableFilters[schemaName] = filter
+		window.Offset = 0
+		db := m.Table().Database.GetDatabaseReference()
+		if err := m.LoadTable(db, schemaName, m.UI.CurrentTable); err != nil {
+			d.TableFilters[schemaName] = previous
+			m.LoadTable(db, schemaName, m.UI.CurrentTable)
+			m.DisplayMessage(fmt.Sprintf("%v", err))
+			return
+		}
+	}
+	m.MoveCursorToRow(0)
+
+	if filter.Active() {
+		m.WriteMessage(fmt.Sprintf("%d record(s) in %s match %s", m.GetRowCount(), schemaName, filter))
+	} else {
+		m.WriteMessage(fmt.Sprintf("Removed filter from %s", schemaName))
+	}
+}
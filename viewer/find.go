@@ -0,0 +1,290 @@
+package viewer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mathaou/termdbms/database"
+	"github.com/mathaou/termdbms/list"
+	"github.com/mathaou/termdbms/tuiutil"
+)
+
+const (
+	FindRowLimit = 100 // matching rows looked at per table, so :find stays quick on big databases
+)
+
+// SearchHit is a cell that :find found
+type SearchHit struct {
+	Table  string
+	Column string
+	Key    map[string]interface{} // row identity, nil when the table doesn't have one
+	Value  string
+}
+
+func (h SearchHit) Title() string {
+	return fmt.Sprintf("%s.%s", h.Table, h.Column)
+}
+
+func (h SearchHit) Description() string {
+	return h.Value
+}
+
+func (h SearchHit) FilterValue() string {
+	return h.Title() + " " + h.Value
+}
+
+type findItemDelegate struct{}
+
+func (d findItemDelegate) Height() int  { return 1 }
+func (d findItemDelegate) Spacing() int { return 0 }
+func (d findItemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
+	return nil
+}
+
+func (d findItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	localStyle := style.Copy()
+	i, ok := listItem.(SearchHit)
+	if !ok {
+		return
+	}
+
+	digits := len(fmt.Sprintf("%d", len(m.Items()))) + 1
+	incomingDigits := len(fmt.Sprintf("%d", index+1))
+
+	if !tuiutil.Ascii {
+		localStyle = style.Copy().Faint(true)
+	}
+
+	str := fmt.Sprintf("%d) %s%s | ", index+1, strings.Repeat(" ", digits-incomingDigits),
+		i.Title())
+	value := strings.ReplaceAll(i.Value, "\n", " ")
+	value = value[0:Max(Min(len(value), TUIWidth-10-len(str)), 0)] // padding + tab + padding
+	str += localStyle.Render(value)
+
+	localStyle = style.Copy().PaddingLeft(4)
+
+	fn := localStyle.Render
+	if index == m.Index() {
+		fn = func(s string) string {
+			localStyle = style.Copy().
+				PaddingLeft(2)
+			if !tuiutil.Ascii {
+				localStyle = localStyle.
+					Foreground(lipgloss.Color(tuiutil.HeaderTopForeground()))
+			}
+
+			return lipgloss.JoinHorizontal(lipgloss.Left,
+				localStyle.
+					Render("> "),
+				style.Render(s))
+		}
+	}
+
+	fmt.Fprint(w, fn(str))
+}
+
+// FindResultMsg is sent back to Update when :find is done
+type FindResultMsg struct {
+	ID      int
+	Text    string
+	Hits    []list.Item
+	Skipped []string // tables that couldn't be searched, and why
+	Err     error
+}
+
+// FindInTables searches every text column of tables for text. A table that can't be searched is skipped, and ends up
+// in the tables it gives back along with why. Only cancelling ctx stops the search
+func FindInTables(ctx context.Context, d database.Database, tables []string, text string) ([]list.Item, []string, error) {
+	db := d.GetDatabaseReference()
+
+	var (
+		hits    []list.Item
+		skipped []string
+	)
+	for _, schemaName := range tables {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		columns, err := d.GetColumns(schemaName)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s (%v)", schemaName, err))
+			continue
+		}
+
+		var textColumns []string
+		for _, c := range columns {
+			if database.IsTextType(c.Type) {
+				textColumns = append(textColumns, c.Name)
+			}
+		}
+		if len(textColumns) == 0 {
+			continue
+		}
+
+		tableHits, err := findInTable(ctx, d, db, schemaName, textColumns, text)
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		} else if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s (%v)", schemaName, err))
+			continue
+		}
+		hits = append(hits, tableHits...)
+	}
+
+	return hits, skipped, nil
+}
+
+func findInTable(ctx context.Context, d database.Database, db *sql.DB, schemaName string, columns []string, text string) ([]list.Item, error) {
+	identity, _ := d.GetRowIdentity(schemaName)
+
+	// key columns come first, then the text columns. Read by position since a key can be a text column too
+	var selected []string
+	if identity != nil {
+		for _, k := range identity.Columns {
+			selected = append(selected, GetKeyColumnExpression(d, identity, k))
+		}
+	}
+	keyCount := len(selected)
+	for _, c := range columns {
+		selected = append(selected, d.QuoteIdentifier(c))
+	}
+
+	condition, values := database.TextSearchCondition(d, columns, text, 1)
+	query := fmt.Sprintf("select %s from %s where %s limit %d",
+		strings.Join(selected, ", "), d.QuoteTableName(schemaName), condition, FindRowLimit)
+
+	rows, err := db.QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columnTypes, _ := rows.ColumnTypes()
+	convert := func(row []interface{}, i int) interface{} {
+		var columnType *sql.ColumnType
+		if i < len(columnTypes) {
+			columnType = columnTypes[i]
+		}
+		return database.ConvertScannedValue(row[i], columnType)
+	}
+
+	var hits []list.Item
+	needle := strings.ToLower(text)
+	for rows.Next() {
+		row := make([]interface{}, len(selected))
+		pointers := make([]interface{}, len(selected))
+		for i := range row {
+			pointers[i] = &row[i]
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		var key map[string]interface{}
+		if identity != nil {
+			key = make(map[string]interface{})
+			for i, k := range identity.Columns {
+				key[k] = convert(row, i)
+			}
+		}
+
+		for i, c := range columns {
+			value := GetStringRepresentationOfInterface(convert(row, keyCount+i))
+			if strings.Contains(strings.ToLower(value), needle) {
+				hits = append(hits, SearchHit{
+					Table:  schemaName,
+					Column: c,
+					Key:    key,
+					Value:  value,
+				})
+			}
+		}
+	}
+
+	return hits, rows.Err()
+}
+
+// OpenFind starts :find in the background like a :sql query, HandleFindResult shows what it found
+func OpenFind(m *TuiModel, text string) {
+	ExitToDefaultView(m)
+	if text == "" {
+		m.WriteMessage("Nothing to find, use :find <text>")
+		return
+	}
+
+	queryCount++
+	m.Query = &RunningQuery{
+		ID:        queryCount,
+		Statement: ":find " + text,
+		Find:      text,
+	}
+}
+
+// HandleFindResult shows the results of :find as a list, and which tables couldn't be searched
+func HandleFindResult(m *TuiModel, msg FindResultMsg) {
+	q, ok := finishQuery(m, msg.ID)
+	if !ok {
+		return
+	}
+	if msg.Err != nil {
+		if q.Cancelled {
+			m.WriteMessage(fmt.Sprintf("Cancelled :find after %s", time.Since(q.Started).Round(time.Millisecond)))
+			return
+		}
+		m.DisplayMessage(fmt.Sprintf("%v", msg.Err))
+		return
+	}
+
+	skipped := ""
+	if len(msg.Skipped) > 0 {
+		skipped = fmt.Sprintf(", couldn't search %s", strings.Join(msg.Skipped, ", "))
+	}
+	if len(msg.Hits) == 0 {
+		m.WriteMessage(fmt.Sprintf("Couldn't find %s in any table%s", msg.Text, skipped))
+		return
+	}
+
+	m.FindList = list.NewModel(msg.Hits, findItemDelegate{}, TUIWidth, TUIHeight)
+	m.FindList.Title = fmt.Sprintf("Results for \"%s\"", msg.Text)
+	m.FindList.SetFilteringEnabled(true)
+	m.FindList.SetShowPagination(true)
+	m.FindList.SetShowTitle(true)
+	m.UI.ShowFind = true
+	if skipped != "" {
+		m.WriteMessage(fmt.Sprintf("Found %d result(s)%s", len(msg.Hits), skipped))
+	}
+}
+
+func HandleFindEvents(m *TuiModel, str string, command *tea.Cmd, msg tea.Msg) {
+	state := m.FindList.FilterState()
+	if (str == "q" || str == "esc" || str == "enter") && state != list.Filtering {
+		hit, ok := m.FindList.SelectedItem().(SearchHit)
+		ExitToDefaultView(m)
+		if str == "enter" && ok {
+			JumpToHit(m, hit)
+		}
+		m.FindList.ResetFilter()
+	} else {
+		m.FindList, *command = m.FindList.Update(msg)
+	}
+}
+
+// JumpToHit opens the table a :find result is in with the cursor on its cell
+func JumpToHit(m *TuiModel, hit SearchHit) {
+	err := m.SetCursorPosition(CursorPosition{
+		Table:  hit.Table,
+		Key:    hit.Key,
+		Column: hit.Column,
+	})
+	if err != nil {
+		m.WriteMessage(fmt.Sprintf("%v", err))
+	} else if hit.Key == nil {
+		m.WriteMessage(fmt.Sprintf("Table %s has no primary key or rowid to find the row by", hit.Table))
+	}
+}
@@ -58,6 +58,7 @@ func init() {
 			m.SwapTableValues(&from, to)
 			m.Table().Database.CloseDatabaseReference()
 			m.Table().Database.SetDatabaseReference(from.Database.GetFileName())
+			m.InvalidateTableWindows() // row counts may have changed
 
 			m.RedoStack = m.RedoStack[0 : len(m.RedoStack)-1] // pop
 		}
@@ -84,6 +85,7 @@ func init() {
 			m.SwapTableValues(&from, to)
 			t.Database.CloseDatabaseReference()
 			t.Database.SetDatabaseReference(from.Database.GetFileName())
+			m.InvalidateTableWindows() // row counts may have changed
 
 			m.UndoStack = m.UndoStack[0 : len(m.UndoStack)-1] // pop
 		}
@@ -98,13 +100,14 @@ func init() {
 			return nil
 		}
 		m.UI.EditModeEnabled = true
+		m.UI.EditTouched = false
 		raw, _, _ := m.GetSelectedOption()
 		if raw == nil {
 			m.UI.EditModeEnabled = false
 			return nil
 		}
 
-		str := GetStringRepresentationOfInterface(*raw)
+		str := GetEditableStringOfInterface(*raw)
 		// so if the selected text is wider than Viewport width or if it has newlines do format mode
 		if lipgloss.Width(str+m.TextInput.Model.Prompt) > m.Viewport.Width ||
 			strings.Count(str, "\n") > 0 { // enter format view
@@ -130,14 +133,70 @@ func init() {
 
 		return cmd
 	}
+	GlobalCommands["i"] = func(m *TuiModel) tea.Cmd {
+		if m.QueryData != nil || m.QueryResult != nil { // editing not allowed in query view mode
+			return nil
+		}
+		OpenInsertForm(m)
+
+		return nil
+	}
+	GlobalCommands["x"] = func(m *TuiModel) tea.Cmd {
+		PromptDelete(m)
+
+		return nil
+	}
+	GlobalCommands["v"] = func(m *TuiModel) tea.Cmd {
+		ToggleRowSelection(m)
+
+		return nil
+	}
+	GlobalCommands["/"] = func(m *TuiModel) tea.Cmd {
+		OpenFilterBar(m)
+
+		return nil
+	}
+	GlobalCommands["o"] = func(m *TuiModel) tea.Cmd {
+		CycleSort(m)
+
+		return nil
+	}
+	GlobalCommands["e"] = func(m *TuiModel) tea.Cmd {
+		if !m.UI.RenderSelection {
+			ShowSchema(m)
+		}
+
+		return nil
+	}
+	GlobalCommands["g"] = func(m *TuiModel) tea.Cmd {
+		if !m.UI.RenderSelection {
+			FollowForeignKey(m)
+		}
+
+		return nil
+	}
+	GlobalCommands["backspace"] = func(m *TuiModel) tea.Cmd {
+		if !m.UI.RenderSelection {
+			GoBack(m)
+		}
+
+		return nil
+	}
 	GlobalCommands["p"] = func(m *TuiModel) tea.Cmd {
-		if m.UI.RenderSelection {
+		if m.UI.RenderSelection && m.UI.SelectedBlob != nil {
+			fn, err := WriteBlobFile(m, m.UI.SelectedBlob)
+			if err != nil {
+				m.WriteMessage(fmt.Sprintf("%v", err))
+			} else {
+				m.WriteMessage(fmt.Sprintf("Wrote BLOB to %s", fn))
+			}
+		} else if m.UI.RenderSelection {
 			fn, _ := WriteTextFile(m, m.Data().EditTextBuffer)
 			m.WriteMessage(fmt.Sprintf("Wrote selection to %s", fn))
-		} else if m.QueryData != nil || m.QueryResult != nil || database.IsCSV {
-			WriteCSV(m)
+		} else {
+			Export(m, "csv")
 		}
-		go Program.Send(tea.KeyMsg{})
+		go Program.Send(RedrawMsg{})
 		return nil
 	}
 	GlobalCommands["c"] = func(m *TuiModel) tea.Cmd {
@@ -158,6 +217,7 @@ func init() {
 		}
 
 		// fix spacing and whatnot
+		m.UI.SelectedRows = nil
 		m.TableStyle = m.TableStyle.Width(m.CellWidth())
 		m.MouseData.Y = HeaderHeight
 		m.MouseData.X = 0
@@ -174,6 +234,7 @@ func init() {
 		}
 
 		// fix spacing and whatnot
+		m.UI.SelectedRows = nil
 		m.TableStyle = m.TableStyle.Width(m.CellWidth())
 		m.MouseData.Y = HeaderHeight
 		m.MouseData.X = 0
@@ -199,7 +260,7 @@ func init() {
 		return nil
 	}
 	GlobalCommands["s"] = func(m *TuiModel) tea.Cmd {
-		max := len(m.GetSchemaData()[m.GetHeaders()[m.GetColumn()]])
+		max := m.GetRowCount()
 
 		if m.MouseData.Y-HeaderHeight+m.Viewport.YOffset < max-1 {
 			m.MouseData.Y++
@@ -0,0 +1,169 @@
+package viewer
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mathaou/termdbms/database"
+	"github.com/mathaou/termdbms/tuiutil"
+)
+
+// NewCSVOptions makes the options for reading a csv file from the way they're typed as flags. The delimiter goes
+// by the file's extension when it's not given
+func NewCSVOptions(fileName, delimiter, comment, encoding, types string, lazyQuotes, header bool) (tuiutil.CSVOptions, error) {
+	options := tuiutil.CSVOptions{
+		KeepOriginalColumns: true,
+		Delimiter:           tuiutil.GetCSVDelimiter(fileName),
+		LazyQuotes:          lazyQuotes,
+		NoHeader:            !header,
+	}
+
+	var err error
+	if delimiter != "" {
+		if options.Delimiter, err = ParseDelimiter(delimiter); err != nil {
+			return options, err
+		}
+	}
+	if comment != "" {
+		r, size := utf8.DecodeRuneInString(comment)
+		if size != len(comment) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError || r == options.Delimiter {
+			return options, fmt.Errorf("%q can't be used to start comments, it has to be a single character other than the delimiter", comment)
+		}
+		options.Comment = r
+	}
+	if options.Encoding, err = tuiutil.ParseEncoding(encoding); err != nil {
+		return options, err
+	}
+	options.Types, err = tuiutil.ParseColumnTypes(types)
+
+	return options, err
+}
+
+// ParseImportArgs reads what was typed after :import, its flags then the csv file and the table to put it in,
+// named after the file when left out
+func ParseImportArgs(args string) (tuiutil.CSVOptions, string, string, error) {
+	flags := flag.NewFlagSet(":import", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	delimiter := flags.String("d", "", "")
+	comment := flags.String("comment", "", "")
+	encoding := flags.String("encoding", "", "")
+	types := flags.String("types", "", "")
+	lazyQuotes := flags.Bool("lazy", false, "")
+	header := flags.Bool("header", true, "")
+	if err := flags.Parse(strings.Fields(args)); err != nil {
+		return tuiutil.CSVOptions{}, "", "", err
+	}
+	if flags.NArg() == 0 || flags.NArg() > 2 {
+		return tuiutil.CSVOptions{}, "", "", errors.New("Use :import [flags] <file> [table], flags go before the file name")
+	}
+
+	fileName, tableName := flags.Arg(0), flags.Arg(1)
+	if tableName == "" {
+		tableName = tuiutil.GetCSVTableName(fileName)
+	}
+	options, err := NewCSVOptions(fileName, *delimiter, *comment, *encoding, *types, *lazyQuotes, *header)
+
+	return options, fileName, tableName, err
+}
+
+// CSVImport is a csv file :import reads into a new table in the background, see StartQuery
+type CSVImport struct {
+	rows, read, size int64 // progress, written by the import and read by the footer with sync/atomic
+	FileName         string
+	TableName        string
+	Options          tuiutil.CSVOptions
+}
+
+// ImportResultMsg is sent back to Update when a CSVImport is done
+type ImportResultMsg struct {
+	ID   int
+	Rows int
+	Err  error
+}
+
+// setProgress is the tuiutil.CSVOptions Progress of the import
+func (c *CSVImport) setProgress(rows int, read, size int64) {
+	atomic.StoreInt64(&c.rows, int64(rows))
+	atomic.StoreInt64(&c.read, read)
+	atomic.StoreInt64(&c.size, size)
+}
+
+// GetProgress is how many rows have been imported so far and how much of the file that is, in percent
+func (c *CSVImport) GetProgress() (int64, int64) {
+	rows, read, size := atomic.LoadInt64(&c.rows), atomic.LoadInt64(&c.read), atomic.LoadInt64(&c.size)
+	if size <= 0 {
+		return rows, 0
+	}
+
+	return rows, read * 100 / size
+}
+
+// ImportFile handles :import, it queues reading a csv file into a new table of the database which then gets shown,
+// see HandleImportResult
+func ImportFile(m *TuiModel, args string) {
+	ExitToDefaultView(m)
+
+	if _, ok := m.DefaultTable.Database.(*database.SQLite); !ok {
+		m.WriteMessage("Only SQLite databases can import csv files")
+		return
+	}
+
+	options, fileName, tableName, err := ParseImportArgs(args)
+	if err != nil {
+		m.WriteMessage(fmt.Sprintf("%v", err))
+		return
+	}
+	if exists, _ := Exists(fileName); !exists {
+		m.WriteMessage(fmt.Sprintf("%s doesn't exist", fileName))
+		return
+	}
+
+	queryCount++
+	m.Query = &RunningQuery{
+		ID:        queryCount,
+		Statement: ":import" + args,
+		Import: &CSVImport{
+			FileName:  fileName,
+			TableName: tableName,
+			Options:   options,
+		},
+	}
+	m.Query.undoOld, m.Query.undoNew = populateUndo(m)
+}
+
+// HandleImportResult shows the table an :import made, or throws away the undo step when it failed
+func HandleImportResult(m *TuiModel, msg ImportResultMsg) {
+	q, ok := finishQuery(m, msg.ID)
+	if !ok {
+		return
+	}
+	if msg.Err != nil {
+		discardUndo(m, q.undoOld, q.undoNew)
+		if q.Cancelled {
+			m.WriteMessage(fmt.Sprintf("Cancelled :import after %s", time.Since(q.Started).Round(time.Millisecond)))
+			return
+		}
+		m.DisplayMessage(fmt.Sprintf("%v", msg.Err))
+		return
+	}
+	if err := m.SetModel(nil, m.DefaultTable.Database.GetDatabaseReference()); err != nil {
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+
+	// show the new table
+	for index := 1; index <= len(m.DefaultData.TableIndexMap); index++ {
+		if m.DefaultData.TableIndexMap[index] == q.Import.TableName {
+			m.UI.CurrentTable = index
+			break
+		}
+	}
+	m.WriteMessage(fmt.Sprintf("Imported %d row(s) from %s into %s", msg.Rows, filepath.Base(q.Import.FileName), q.Import.TableName))
+}
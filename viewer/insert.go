@@ -0,0 +1,165 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mathaou/termdbms/database"
+)
+
+const (
+	insertFormComment = "--"
+	nullKeyword       = "NULL"
+)
+
+// InsertState keeps track of the :insert form
+type InsertState struct {
+	TableName string
+	Defaults  map[string]string // what each column was pre-filled with, values left alone fall back to the database default
+	Types     map[string]string // declared type of each column, what the values get parsed as
+}
+
+// OpenInsertForm opens a format mode buffer with a "column = value" line for each column of the
+// current schema, pre-filled with the defaults declared in the schema
+func OpenInsertForm(m *TuiModel) {
+	schemaName := m.GetSchemaName()
+	if err := m.CheckEditable(schemaName); err != nil {
+		ExitToDefaultView(m)
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+	columns, err := m.Table().Database.GetColumns(schemaName)
+	if err != nil {
+		ExitToDefaultView(m)
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%s new row for %s. Values left alone get the column default, %s inserts null and '%s' the text. :w to insert\n",
+		insertFormComment, schemaName, nullKeyword, nullKeyword))
+	defaults := make(map[string]string)
+	types := make(map[string]string)
+	for _, c := range columns {
+		types[c.Name] = c.Type
+		value := nullKeyword
+		if c.Default.Valid {
+			value = unquoteDefault(c.Default.String)
+		} else if c.NotNull {
+			value = ""
+		}
+		defaults[c.Name] = value
+		builder.WriteString(fmt.Sprintf("%s = %s\n", c.Name, value))
+	}
+
+	CreatePopulatedBuffer(m, nil, strings.TrimSuffix(builder.String(), "\n"))
+	m.UI.InsertEdit = true
+	m.InsertForm = InsertState{
+		TableName: schemaName,
+		Defaults:  defaults,
+		Types:     types,
+	}
+}
+
+// unquoteDefault shows string literal defaults ('abc') without the quotes
+func unquoteDefault(value string) string {
+	if len(value) > 1 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+
+	return value
+}
+
+// splitInsertLine splits a "column = value" line of the insert form. Column names can have = in them, so the line is
+// matched against the columns there are, the longest one first
+func splitInsertLine(line string, columns map[string]string) (string, string, bool) {
+	column, value, found := "", "", false
+	for _, text := range []string{line, strings.TrimLeft(line, " \t")} {
+		for name := range columns {
+			if !strings.HasPrefix(text, name) || found && len(name) <= len(column) {
+				continue
+			}
+			rest := strings.TrimLeft(text[len(name):], " \t")
+			if strings.HasPrefix(rest, "=") {
+				column, value, found = name, strings.TrimSpace(rest[1:]), true
+			}
+		}
+	}
+
+	return column, value, found
+}
+
+// parseInsertForm turns the lines of the insert form into the values of the new row
+func parseInsertForm(m *TuiModel, input string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	for _, line := range SplitLines(input) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, insertFormComment) {
+			continue
+		}
+
+		column, value, ok := splitInsertLine(line, m.InsertForm.Defaults)
+		if !ok {
+			if !strings.Contains(line, "=") {
+				return nil, fmt.Errorf("expected \"column = value\", got %s", trimmed)
+			}
+			return nil, fmt.Errorf("%s has no column named %s", m.InsertForm.TableName,
+				strings.TrimSpace(strings.SplitN(line, "=", 2)[0]))
+		}
+		def := m.InsertForm.Defaults[column]
+
+		if value == def { // left alone
+			continue
+		}
+
+		if value == nullKeyword {
+			values[column] = nil
+			continue
+		}
+
+		parsed, err := database.ParseValue(m.DefaultTable.Database, m.InsertForm.Types[column], unquoteDefault(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", column, err)
+		}
+		values[column] = parsed
+	}
+
+	return values, nil
+}
+
+func handleInsertMode(m *TuiModel, input string) {
+	values, err := parseInsertForm(m, input)
+	if err != nil {
+		m.TextInput.Model.SetValue("")
+		m.WriteMessage(fmt.Sprintf("%v", err))
+		return
+	}
+
+	schemaName := m.InsertForm.TableName
+	old, n := populateUndo(m)
+	q := &database.Insert{
+		Identity: m.DefaultData.TableKeys[schemaName],
+	}
+	_, err = database.ProcessSqlQueryForDatabaseType(q, values, schemaName, "", &m.DefaultTable.Database)
+	if err != nil {
+		discardUndo(m, old, n)
+		ExitToDefaultView(m)
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+
+	ExitToDefaultView(m)
+	m.Data().EditTextBuffer = ""
+	err = m.LoadTable(m.DefaultTable.Database.GetDatabaseReference(), schemaName, m.UI.CurrentTable)
+	if err != nil {
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+
+	if q.Key != nil {
+		if row := m.FindRow(q.Key); row > -1 {
+			m.MoveCursorToRow(row)
+		}
+	}
+	m.WriteMessage(fmt.Sprintf("Inserted a row into %s", schemaName))
+}
@@ -30,7 +30,11 @@ func ExitToDefaultView(m *TuiModel) {
 	m.UI.EditModeEnabled = false
 	m.UI.FormatModeEnabled = false
 	m.UI.SQLEdit = false
+	m.UI.InsertEdit = false
+	m.UI.FilterEdit = false
+	m.UI.EditTouched = false
 	m.UI.ShowClipboard = false
+	m.UI.ShowFind = false
 	m.UI.CanFormatScroll = false
 	m.Format.CursorY = 0
 	m.Format.CursorX = 0
@@ -39,6 +43,7 @@ func ExitToDefaultView(m *TuiModel) {
 	m.Format.RunningOffsets = nil
 	m.FormatInput.Model.Reset()
 	m.TextInput.Model.Reset()
+	m.TextInput.Model.Prompt = editPrompt
 	m.Viewport.YOffset = 0
 }
 
@@ -74,6 +79,11 @@ func EditEnter(m *TuiModel) {
 		input    string
 	)
 
+	if m.UI.FilterEdit { // everything typed into the filter bar is the filter
+		ApplyFilter(m, i)
+		return
+	}
+
 	if i == ":q" { // quit mod mode
 		ExitToDefaultView(m)
 		return
@@ -99,6 +109,10 @@ func EditEnter(m *TuiModel) {
 			ExitToDefaultView(m)
 			return
 		}
+		if input == ":export" || strings.HasPrefix(input, ":export ") { // works on query results too
+			Export(m, strings.TrimPrefix(input, ":export"))
+			return
+		}
 		if m.QueryData != nil {
 			m.TextInput.Model.SetValue("")
 			m.WriteMessage("Cannot manipulate database through UI while query results are being displayed.")
@@ -108,7 +122,7 @@ func EditEnter(m *TuiModel) {
 			m.DisplayMessage(GetHelpText())
 			return
 		} else if input == ":edit" {
-			str := GetStringRepresentationOfInterface(*original)
+			str := GetEditableStringOfInterface(*original)
 			PrepareFormatMode(m)
 			if conv, err := FormatJson(str); err == nil { // if json prettify
 				d.EditTextBuffer = conv
@@ -123,6 +137,56 @@ func EditEnter(m *TuiModel) {
 		} else if input == ":new" {
 			CreateEmptyBuffer(m, original)
 			return
+		} else if input == ":null" {
+			if original == nil {
+				return
+			}
+			key, err := m.GetRowKey()
+			if err != nil {
+				ExitToDefaultView(m)
+				m.DisplayMessage(fmt.Sprintf("%v", err))
+				return
+			}
+			setCellValue(m, key, nil, original)
+			m.TextInput.Model.SetValue("")
+			return
+		} else if input == ":delete" {
+			m.UI.EditModeEnabled = false
+			m.TextInput.Model.SetValue("")
+			PromptDelete(m)
+			return
+		} else if input == ":attach" || strings.HasPrefix(input, ":attach ") {
+			AttachDatabase(m, strings.TrimPrefix(input, ":attach"))
+			return
+		} else if input == ":import" || strings.HasPrefix(input, ":import ") {
+			ImportFile(m, strings.TrimPrefix(input, ":import"))
+			return
+		} else if input == ":schema" {
+			ExitToDefaultView(m)
+			ShowSchema(m)
+			return
+		} else if input == ":blob" || strings.HasPrefix(input, ":blob ") {
+			args := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(input, ":blob")), " ", 2)
+			fileName := ""
+			if len(args) == 2 {
+				fileName = strings.TrimSpace(args[1])
+			}
+			if original == nil {
+				return
+			} else if args[0] == "export" {
+				ExportBlob(m, *original, fileName)
+			} else if args[0] == "import" {
+				ImportBlob(m, original, fileName)
+			} else {
+				m.WriteMessage("Use :blob export [file] or :blob import <file>")
+			}
+			return
+		} else if input == ":find" || strings.HasPrefix(input, ":find ") {
+			OpenFind(m, strings.TrimSpace(strings.TrimPrefix(input, ":find")))
+			return
+		} else if input == ":insert" {
+			OpenInsertForm(m)
+			return
 		} else if input == ":sql" {
 			CreateEmptyBuffer(m, original)
 			m.UI.SQLEdit = true
@@ -146,7 +210,7 @@ func EditEnter(m *TuiModel) {
 		}
 	}
 
-	if original != nil && *original == input {
+	if original != nil && IsUnchanged(*original, input, m.UI.EditTouched) {
 		ExitToDefaultView(m)
 		return
 	}
@@ -173,6 +237,13 @@ func EditEnter(m *TuiModel) {
 		return
 	}
 
+	if m.UI.InsertEdit {
+		if i == ":w" || i == ":wq" {
+			handleInsertMode(m, input)
+		}
+		return
+	}
+
 	if m.UI.SQLEdit {
 		if i == ":exec" {
 			handleSQLMode(m, input)
@@ -201,9 +272,11 @@ func EditEnter(m *TuiModel) {
 		return
 	}
 
-	old, n := populateUndo(m)
-	if old == n || n != m.DefaultTable.Database.GetFileName() {
-		panic(errors.New("could not get database file name"))
+	key, err := m.GetRowKey()
+	if err != nil {
+		ExitToDefaultView(m)
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
 	}
 
 	if _, err := FormatJson(input); err == nil { // if json uglify
@@ -213,86 +286,70 @@ func EditEnter(m *TuiModel) {
 		input = strings.ReplaceAll(input, "\r", "")
 	}
 
-	u := GetInterfaceFromString(input, original)
-	database.ProcessSqlQueryForDatabaseType(&database.Update{
-		Update: u,
-	}, m.GetRowData(), m.GetSchemaName(), m.GetSelectedColumnName(), &t.Database)
-
-	m.UI.EditModeEnabled = false
-	d.EditTextBuffer = ""
-	m.FormatInput.Model.SetValue("")
+	u, err := GetInterfaceFromString(t.Database, m.GetSelectedColumnType(), input, original)
+	if err != nil { // stay in the editor so the input can be fixed
+		if m.UI.FormatModeEnabled {
+			m.TextInput.Model.SetValue("")
+		}
+		if strings.EqualFold(strings.TrimSpace(input), nullKeyword) {
+			m.WriteMessage(fmt.Sprintf("%v, use :null to set the cell to NULL", err))
+		} else {
+			m.WriteMessage(fmt.Sprintf("%v", err))
+		}
+		return
+	}
 
-	*original = input
+	setCellValue(m, key, u, original)
 
 	if m.UI.FormatModeEnabled && i == ":wq" {
 		ExitToDefaultView(m)
 	}
 }
 
-func handleSQLMode(m *TuiModel, input string) {
-	if m.QueryResult != nil {
-		m.QueryResult = nil
+// setCellValue writes value to the selected cell in the database, original being where the cell is kept in memory
+func setCellValue(m *TuiModel, key map[string]interface{}, value interface{}, original *interface{}) {
+	old, n := populateUndo(m)
+	if old != "" && (old == n || n != m.DefaultTable.Database.GetFileName()) {
+		panic(errors.New("could not get database file name"))
 	}
-	m.QueryResult = &TableState{ // perform query
-		Database: m.Table().Database,
-		Data:     make(map[string]interface{}),
+
+	previous := *original
+	*original = value
+
+	affected, err := database.ProcessSqlQueryForDatabaseType(&database.Update{
+		Update: value,
+	}, key, m.GetSchemaName(), m.GetSelectedColumnName(), &m.Table().Database)
+	if err == nil && affected == 0 {
+		err = errors.New("no rows were updated, the row may have been changed or removed")
 	}
-	m.QueryData = &UIData{}
+	if err != nil {
+		*original = previous
+		discardUndo(m, old, n)
+		ExitToDefaultView(m)
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+
+	m.UI.EditModeEnabled = false
+	m.Data().EditTextBuffer = ""
+	m.FormatInput.Model.SetValue("")
+}
 
+func handleSQLMode(m *TuiModel, input string) {
 	firstword := strings.ToLower(strings.Split(input, " ")[0])
-	if exec := firstword == "update" ||
+	exec := firstword == "update" ||
 		firstword == "delete" ||
-		firstword == "insert"; exec {
+		firstword == "insert"
+	if exec {
 		m.QueryData = nil
 		m.QueryResult = nil
-		populateUndo(m)
-		_, err := m.DefaultTable.Database.GetDatabaseReference().Exec(input)
-		if err != nil {
-			ExitToDefaultView(m)
-			m.DisplayMessage(fmt.Sprintf("%v", err))
-			return
-		}
-		var c *sql.Rows
-		defer func() {
-			if c != nil {
-				c.Close()
-			}
-		}()
-		err = m.SetModel(c, m.DefaultTable.Database.GetDatabaseReference())
-		if err != nil {
-			m.DisplayMessage(fmt.Sprintf("%v", err))
-		} else {
-			ExitToDefaultView(m)
-		}
-	} else { // query
-		c, err := m.QueryResult.Database.GetDatabaseReference().Query(input)
-		defer func() {
-			if c != nil {
-				c.Close()
-			}
-		}()
-		if err != nil {
-			m.QueryResult = nil
-			m.QueryData = nil
-			ExitToDefaultView(m)
-			m.DisplayMessage(fmt.Sprintf("%v", err))
-			return
-		}
-
-		i := 0
-
-		m.QueryData.TableHeaders = make(map[string][]string)
-		m.QueryData.TableIndexMap = make(map[int]string)
-		m.QueryData.TableSlices = make(map[string][]interface{})
-		m.QueryData.TableHeadersSlice = []string{}
-
-		m.PopulateDataForResult(c, &i, QueryResultsTableName)
-		ExitToDefaultView(m)
-		m.UI.EditModeEnabled = false
-		m.UI.CurrentTable = 1
-		m.Data().EditTextBuffer = ""
-		m.FormatInput.Model.SetValue("")
 	}
+
+	// runs in the background, results come back through HandleQueryResult
+	QueueQuery(m, input, exec)
+	ExitToDefaultView(m)
+	m.Data().EditTextBuffer = ""
+	m.FormatInput.Model.SetValue("")
 }
 
 func populateUndo(m *TuiModel) (old string, new string) {
@@ -308,6 +365,9 @@ func populateUndo(m *TuiModel) (old string, new string) {
 
 	switch m.DefaultTable.Database.(type) {
 	case *database.SQLite:
+		if exists, _ := Exists(m.DefaultTable.Database.GetFileName()); !exists {
+			break // in-memory databases have no file to copy
+		}
 		deepCopy := m.CopyMap()
 		// THE GLOBALIST TAKEOVER
 		deepState := TableState{
@@ -330,3 +390,15 @@ func populateUndo(m *TuiModel) (old string, new string) {
 
 	return old, new
 }
+
+// discardUndo throws away the state populateUndo saved for a change that never made it to the database
+func discardUndo(m *TuiModel, old, new string) {
+	if old == "" || len(m.UndoStack) == 0 {
+		return
+	}
+
+	m.UndoStack = m.UndoStack[:len(m.UndoStack)-1]
+	m.DefaultTable.Database.CloseDatabaseReference()
+	m.DefaultTable.Database.SetDatabaseReference(old)
+	os.Remove(new)
+}
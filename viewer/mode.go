@@ -24,6 +24,7 @@ var (
 func PrepareFormatMode(m *TuiModel) {
 	m.UI.FormatModeEnabled = true
 	m.UI.EditModeEnabled = false
+	m.UI.EditTouched = false
 	m.TextInput.Model.SetValue("")
 	m.FormatInput.Model.SetValue("")
 	m.FormatInput.Model.Focus = true
@@ -403,6 +404,10 @@ func HandleEditMode(m *TuiModel, str string) {
 	}
 
 	if str == "esc" {
+		if m.UI.FilterEdit {
+			CloseFilterBar(m)
+			return
+		}
 		selectedInput.SetValue("")
 		return
 	}
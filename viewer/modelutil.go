@@ -7,18 +7,26 @@ import (
 	"os"
 	"strings"
 
-	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/mathaou/termdbms/database"
 	"github.com/mathaou/termdbms/list"
 	"github.com/mathaou/termdbms/tuiutil"
 )
 
+const (
+	WindowSize = 500 // how many rows of a table are kept in memory at once
+)
+
+// RedrawMsg gets the screen drawn again after something changed outside of Update. It isn't a key press, so it
+// can't answer a y/n prompt or cancel a query
+type RedrawMsg struct{}
+
 func (m *TuiModel) WriteMessage(s string) {
 	if Message == "" {
 		Message = s
 		MIP = true
-		go Program.Send(tea.KeyMsg{}) // trigger update
-		go Program.Send(tea.KeyMsg{}) // trigger update for sure hack gross but w/e
+		go Program.Send(RedrawMsg{}) // trigger update
+		go Program.Send(RedrawMsg{}) // trigger update for sure hack gross but w/e
 	}
 }
 
@@ -28,17 +36,10 @@ func (m *TuiModel) CopyMap() (to map[string]interface{}) {
 
 	for k, v := range from {
 		if copyValues, ok := v.(map[string][]interface{}); ok {
-			columnNames := m.Data().TableHeaders[k]
 			columnValues := make(map[string][]interface{})
-			// golang wizardry
-			columns := make([]interface{}, len(columnNames))
-
-			for i := range columns {
-				columns[i] = copyValues[columnNames[i]]
-			}
 
-			for i, colName := range columnNames {
-				val := columns[i].([]interface{})
+			// every column, including hidden row identity columns that aren't in the headers
+			for colName, val := range copyValues {
 				buffer := make([]interface{}, len(val))
 				for k := range val {
 					buffer[k] = val[k]
@@ -54,14 +55,11 @@ func (m *TuiModel) CopyMap() (to map[string]interface{}) {
 }
 
 // GetNewModel returns a TuiModel struct with some fields set
-func GetNewModel(baseFileName string, db *sql.DB) TuiModel {
+func GetNewModel(connection *database.ConnectionInfo, db *sql.DB) TuiModel {
 	m := TuiModel{
 		DefaultTable: TableState{
-			Database: &database.SQLite{
-				FileName: baseFileName,
-				Database: db,
-			},
-			Data: make(map[string]interface{}),
+			Database: database.GetDatabaseImplementation(connection, db),
+			Data:     make(map[string]interface{}),
 		},
 		Format: FormatState{
 			EditSlices:     nil,
@@ -85,6 +83,10 @@ func GetNewModel(baseFileName string, db *sql.DB) TuiModel {
 			TableHeadersSlice: []string{},
 			TableSlices:       make(map[string][]interface{}),
 			TableIndexMap:     make(map[int]string),
+			TableKeys:         make(map[string]*database.RowIdentity),
+			TableWindows:      make(map[string]*TableWindow),
+			TableSorts:        make(map[string]ColumnSort),
+			TableFilters:      make(map[string]RowFilter),
 		},
 		TextInput: LineEdit{
 			Model: tuiutil.NewModel(),
@@ -93,6 +95,7 @@ func GetNewModel(baseFileName string, db *sql.DB) TuiModel {
 			Model: tuiutil.NewModel(),
 		},
 		Clipboard: []list.Item{},
+		Spinner:   spinner.NewModel(),
 	}
 	m.FormatInput.Model.Prompt = ""
 
@@ -122,8 +125,6 @@ func GetNewModel(baseFileName string, db *sql.DB) TuiModel {
 func (m *TuiModel) SetModel(c *sql.Rows, db *sql.DB) error {
 	var err error
 
-	indexMap := 0
-
 	// gets all the schema names of the database
 	tableNamesQuery := m.Table().Database.GetTableNamesQuery()
 	rows, err := db.Query(tableNamesQuery)
@@ -131,39 +132,152 @@ func (m *TuiModel) SetModel(c *sql.Rows, db *sql.DB) error {
 		return err
 	}
 
-	defer rows.Close()
-
-	// for each schema
+	// read every name up front, sqlite only hands out one connection at a time
+	var schemaNames []string
+	m.DefaultData.TableKinds = make(map[string]string)
 	for rows.Next() {
-		var schemaName string
-		rows.Scan(&schemaName)
-
-		// couldn't get prepared statements working and gave up because it was very simple
-		var statement strings.Builder
-		statement.WriteString("select * from ")
-		statement.WriteString(schemaName)
-		getAll := statement.String()
-
-		if c != nil {
-			c.Close()
-			c = nil
-		}
-		c, err = db.Query(getAll)
-		if err != nil {
-			panic(err)
-		}
-
-		m.PopulateDataForResult(c, &indexMap, schemaName)
+		var schemaName, kind string
+		rows.Scan(&schemaName, &kind)
+		schemaNames = append(schemaNames, schemaName)
+		m.DefaultData.TableKinds[schemaName] = kind
+	}
+	rows.Close()
+
+	// tables only get their rows loaded once they're looked at, so this doesn't depend on how big they are
+	m.DefaultData.TableIndexMap = make(map[int]string)
+	m.DefaultData.TableWindows = make(map[string]*TableWindow)
+	m.DefaultData.TableSorts = make(map[string]ColumnSort) // columns might not be there anymore
+	m.DefaultData.TableFilters = make(map[string]RowFilter)
+	for i, schemaName := range schemaNames {
+		m.DefaultData.TableIndexMap[i+1] = schemaName
+		m.DefaultData.TableWindows[schemaName] = &TableWindow{}
 	}
 
 	// set the first table to be initial view
 	m.UI.CurrentTable = 1
+	if len(schemaNames) > 0 {
+		return m.LoadTable(db, schemaNames[0], 1)
+	}
+
+	return nil
+}
+
+// LoadTable (re)counts the rows of a single schema and loads the rows around where it was last viewed, index being its place in TableIndexMap
+func (m *TuiModel) LoadTable(db *sql.DB, schemaName string, index int) error {
+	// tables without a primary key get their rowid selected alongside the data so edits can find the row again
+	identity, _ := m.Table().Database.GetRowIdentity(schemaName)
+	m.DefaultData.TableKeys[schemaName] = identity
+
+	window := m.DefaultData.TableWindows[schemaName]
+	if window == nil {
+		window = &TableWindow{}
+		m.DefaultData.TableWindows[schemaName] = window
+	}
+
+	total := 0
+	where, values := m.GetTableCondition(schemaName, 1)
+	err := db.QueryRow("select count(*) from "+m.Table().Database.QuoteTableName(schemaName)+where, values...).Scan(&total)
+	if err != nil {
+		return err
+	}
+	window.Total = total
+	window.Loaded = true
+
+	return m.LoadWindow(db, schemaName, index, Min(window.Offset, Max(total-WindowSize, 0)))
+}
+
+// LoadWindow replaces the loaded rows of a schema with the WindowSize rows starting at offset
+func (m *TuiModel) LoadWindow(db *sql.DB, schemaName string, index, offset int) error {
+	identity := m.DefaultData.TableKeys[schemaName]
+
+	// couldn't get prepared statements working and gave up because it was very simple
+	d := m.Table().Database
+	var statement strings.Builder
+	statement.WriteString("select ")
+	if identity != nil && identity.Hidden {
+		for _, k := range identity.Columns {
+			statement.WriteString(fmt.Sprintf("%s as %s, ", k, d.QuoteIdentifier(k)))
+		}
+	}
+	statement.WriteString("* from ")
+	statement.WriteString(d.QuoteTableName(schemaName))
+	where, values := m.GetTableCondition(schemaName, 1)
+	statement.WriteString(where)
+	statement.WriteString(GetOrderBy(d, identity, m.DefaultData.TableSorts[schemaName])) // pages have to come back in the same order every time
+	statement.WriteString(fmt.Sprintf(" limit %d offset %d", WindowSize, offset))
+
+	c, err := db.Query(statement.String(), values...)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	index-- // gets moved onto this schema's index when populating
+	m.PopulateDataForResult(c, &index, schemaName)
+	if window := m.DefaultData.TableWindows[schemaName]; window != nil {
+		window.Offset = offset
+	}
 
 	return nil
 }
 
+// LoadRowsInView pages in the rows of the current table under the viewport, if they aren't already loaded
+func (m *TuiModel) LoadRowsInView() {
+	window := m.GetTableWindow()
+	if window == nil || m.UI.RenderSelection || m.UI.EditModeEnabled || m.UI.FormatModeEnabled {
+		return
+	}
+	if m.Query != nil { // the database is busy, sqlite would block until the query is done
+		return
+	}
+
+	db := m.Table().Database.GetDatabaseReference()
+	schemaName := m.GetSchemaName()
+	if !window.Loaded {
+		if err := m.LoadTable(db, schemaName, m.UI.CurrentTable); err != nil {
+			window.Loaded = true // don't keep hammering a table that can't be read
+			m.WriteMessage(fmt.Sprintf("%v", err))
+			return
+		}
+	}
+
+	first := Min(m.Viewport.YOffset, window.Total)
+	last := Min(first+m.Viewport.Height, window.Total)
+	if first >= window.Offset && last <= window.Offset+len(m.GetColumnData()) {
+		return
+	}
+
+	// leave some room behind the viewport so scrolling back up doesn't immediately go back to the database
+	if err := m.LoadWindow(db, schemaName, m.UI.CurrentTable, Max(first-WindowSize/4, 0)); err != nil {
+		m.WriteMessage(fmt.Sprintf("%v", err))
+	}
+}
+
+// InvalidateTableWindows makes every table get recounted and reloaded the next time it's viewed
+func (m *TuiModel) InvalidateTableWindows() {
+	for _, window := range m.DefaultData.TableWindows {
+		window.Loaded = false
+	}
+}
+
+// GetKeyColumnExpression gets how a row identity column is referred to in a query, hidden ones like rowid aren't real columns
+func GetKeyColumnExpression(d database.Database, identity *database.RowIdentity, column string) string {
+	if identity.Hidden {
+		return column
+	}
+
+	return d.QuoteIdentifier(column)
+}
+
 func (m *TuiModel) PopulateDataForResult(c *sql.Rows, indexMap *int, schemaName string) {
+	columnNames, columnValues, _ := ReadRows(c)
+	m.SetDataForResult(columnNames, columnValues, indexMap, schemaName)
+}
+
+// ReadRows reads every row of a result, organized by column. Doesn't touch the model so queries can run in the background
+func ReadRows(c *sql.Rows) ([]string, map[string][]interface{}, error) {
 	columnNames, _ := c.Columns()
+	columnTypes, _ := c.ColumnTypes()
 	columnValues := make(map[string][]interface{})
 
 	for c.Next() { // each row of the table
@@ -179,20 +293,35 @@ func (m *TuiModel) PopulateDataForResult(c *sql.Rows, indexMap *int, schemaName
 
 		for i, colName := range columnNames {
 			val := columnPointers[i].(*interface{})
-			columnValues[colName] = append(columnValues[colName], *val)
+			var columnType *sql.ColumnType
+			if i < len(columnTypes) {
+				columnType = columnTypes[i]
+			}
+			columnValues[colName] = append(columnValues[colName], database.ConvertScannedValue(*val, columnType))
 		}
 	}
 
+	return columnNames, columnValues, c.Err()
+}
+
+// SetDataForResult stores the rows of a schema, in the query results if they're being shown
+func (m *TuiModel) SetDataForResult(columnNames []string, columnValues map[string][]interface{}, indexMap *int, schemaName string) {
+	// hidden row identity columns are kept with the data, but never shown
+	headers := columnNames
+	if identity := m.Data().TableKeys[schemaName]; identity != nil && identity.Hidden {
+		headers = columnNames[len(identity.Columns):]
+	}
+
 	// onto the next schema
 	*indexMap++
 	if m.QueryResult != nil && m.QueryData != nil {
 		m.QueryResult.Data[schemaName] = columnValues
-		m.QueryData.TableHeaders[schemaName] = columnNames // headers for the schema, for later reference
+		m.QueryData.TableHeaders[schemaName] = headers // headers for the schema, for later reference
 		m.QueryData.TableIndexMap[*indexMap] = schemaName
 		return
 	}
-	m.Table().Data[schemaName] = columnValues       // data for schema, organized by column
-	m.Data().TableHeaders[schemaName] = columnNames // headers for the schema, for later reference
+	m.Table().Data[schemaName] = columnValues   // data for schema, organized by column
+	m.Data().TableHeaders[schemaName] = headers // headers for the schema, for later reference
 	// mapping between schema and an int ( since maps aren't deterministic), for later reference
 	m.Data().TableIndexMap[*indexMap] = schemaName
 }
@@ -202,17 +331,10 @@ func (m *TuiModel) SwapTableValues(f, t *TableState) {
 	to := &t.Data
 	for k, v := range *from {
 		if copyValues, ok := v.(map[string][]interface{}); ok {
-			columnNames := m.Data().TableHeaders[k]
 			columnValues := make(map[string][]interface{})
-			// golang wizardry
-			columns := make([]interface{}, len(columnNames))
-
-			for i := range columns {
-				columns[i] = copyValues[columnNames[i]][0]
-			}
 
-			for i, colName := range columnNames {
-				columnValues[colName] = columns[i].([]interface{})
+			for colName, val := range copyValues {
+				columnValues[colName] = val[0].([]interface{})
 			}
 
 			(*to)[k] = columnValues // data for schema, organized by column
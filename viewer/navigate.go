@@ -0,0 +1,233 @@
+package viewer
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/mathaou/termdbms/database"
+)
+
+// CursorPosition is a place in the tables the cursor can be put on
+type CursorPosition struct {
+	Table  string
+	Key    map[string]interface{} // row identity, nil to go by Row instead
+	Row    int                    // absolute row, used when there's no Key
+	Column string
+}
+
+// GetCursorPosition gets where the cursor is in the current table
+func (m *TuiModel) GetCursorPosition() CursorPosition {
+	row := m.GetAbsoluteRow()
+	key, _ := m.GetRowKeyAt(row)
+
+	return CursorPosition{
+		Table:  m.GetSchemaName(),
+		Key:    key,
+		Row:    row,
+		Column: m.GetCursorColumnName(),
+	}
+}
+
+// GetTableIndex gets the place of a table in TableIndexMap, 0 if there's no such table
+func (m *TuiModel) GetTableIndex(schemaName string) int {
+	for i, name := range m.DefaultData.TableIndexMap {
+		if name == schemaName {
+			return i
+		}
+	}
+
+	return 0
+}
+
+// SetCursorPosition opens the table of p with the cursor on its row and column. The row gets found by its Key wherever it
+// is in the table, clearing the filter of the table if that's what hides it
+func (m *TuiModel) SetCursorPosition(p CursorPosition) error {
+	index := m.GetTableIndex(p.Table)
+	if index == 0 {
+		return fmt.Errorf("there's no table named %s", p.Table)
+	}
+
+	if index != m.UI.CurrentTable {
+		m.UI.CurrentTable = index
+		m.UI.SelectedRows = nil
+		m.UI.ExpandColumn = -1
+		m.MouseData.Y = HeaderHeight
+		m.MouseData.X = 0
+		m.Viewport.YOffset = 0
+		m.Scroll.ScrollXOffset = 0
+	}
+
+	db := m.DefaultTable.Database.GetDatabaseReference()
+	window := m.DefaultData.TableWindows[p.Table]
+	if window != nil && !window.Loaded {
+		if err := m.LoadTable(db, p.Table, index); err != nil {
+			return err
+		}
+	}
+
+	m.MoveCursorToColumn(p.Column)
+	row := p.Row
+	if p.Key != nil {
+		row = m.FindRow(p.Key)
+		if filter := m.DefaultData.TableFilters[p.Table]; row < 0 && filter.Active() && window != nil {
+			delete(m.DefaultData.TableFilters, p.Table)
+			window.Offset = 0
+			if err := m.LoadTable(db, p.Table, index); err != nil {
+				return err
+			}
+			row = m.FindRow(p.Key)
+		}
+		if row < 0 {
+			return fmt.Errorf("couldn't find the row in %s anymore", p.Table)
+		}
+	}
+	m.MoveCursorToRow(Max(Min(row, m.GetRowCount()-1), 0))
+
+	return nil
+}
+
+// FollowForeignKey opens the row that the foreign key under the cursor refers to. Where the cursor was goes on
+// NavigationStack, see GoBack
+func FollowForeignKey(m *TuiModel) {
+	if m.QueryData != nil || m.QueryResult != nil {
+		m.WriteMessage("Foreign keys can only be followed from tables, use :d to get back to them")
+		return
+	}
+	if m.GetAbsoluteRow() >= m.GetRowCount() {
+		return
+	}
+
+	schemaName := m.GetSchemaName()
+	column := m.GetCursorColumnName()
+	d := m.Table().Database
+	keys, err := d.GetForeignKeys(schemaName)
+	if err != nil {
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+
+	var fk *database.ForeignKey
+	for i := range keys {
+		for _, c := range keys[i].Columns {
+			if c == column && fk == nil {
+				fk = &keys[i]
+			}
+		}
+	}
+	if fk == nil {
+		m.WriteMessage(fmt.Sprintf("%s isn't a foreign key", column))
+		return
+	}
+
+	row := m.GetRowData()
+	var values []interface{}
+	for _, c := range fk.Columns {
+		if row[c] == nil {
+			m.WriteMessage(fmt.Sprintf("%s is NULL, it doesn't refer to anything", c))
+			return
+		}
+		values = append(values, row[c])
+	}
+
+	referencedColumns, err := fk.GetReferencedColumns(d)
+	if err != nil {
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+	key, err := FindReferencedRow(d, fk.Table, referencedColumns, values)
+	if err != nil {
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+
+	target := CursorPosition{
+		Table: fk.Table,
+		Key:   key,
+	}
+	for i, c := range fk.Columns {
+		if c == column && i < len(referencedColumns) {
+			target.Column = referencedColumns[i]
+		}
+	}
+
+	from := m.GetCursorPosition()
+	if err = m.SetCursorPosition(target); err != nil {
+		m.SetCursorPosition(from)
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+	m.NavigationStack = append(m.NavigationStack, from)
+	m.WriteMessage(fmt.Sprintf("Followed %s.%s to %s, [BACKSPACE] to go back", from.Table, column, fk.Table))
+}
+
+// FindReferencedRow looks up the row identity of the row in table whose columns have the given values
+func FindReferencedRow(d database.Database, table string, columns []string, values []interface{}) (map[string]interface{}, error) {
+	identity, err := d.GetRowIdentity(table)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		selected []string
+		where    []string
+	)
+	for _, k := range identity.Columns {
+		selected = append(selected, GetKeyColumnExpression(d, identity, k))
+	}
+	for i, c := range columns {
+		where = append(where, fmt.Sprintf("%s = %s", d.QuoteIdentifier(c), d.GetPlaceholderForDatabaseType(i+1)))
+	}
+	query := fmt.Sprintf("select %s from %s where %s limit 1",
+		strings.Join(selected, ", "), d.QuoteTableName(table), strings.Join(where, " and "))
+
+	rows, err := d.GetDatabaseReference().Query(query, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("the referenced row isn't in %s", table)
+	}
+
+	key := make([]interface{}, len(selected))
+	pointers := make([]interface{}, len(selected))
+	for i := range key {
+		pointers[i] = &key[i]
+	}
+	if err = rows.Scan(pointers...); err != nil {
+		return nil, err
+	}
+	columnTypes, _ := rows.ColumnTypes()
+
+	result := make(map[string]interface{})
+	for i, k := range identity.Columns {
+		var columnType *sql.ColumnType
+		if i < len(columnTypes) {
+			columnType = columnTypes[i]
+		}
+		result[k] = database.ConvertScannedValue(key[i], columnType)
+	}
+
+	return result, nil
+}
+
+// GoBack puts the cursor back where it was before the last followed foreign key
+func GoBack(m *TuiModel) {
+	if m.QueryData != nil || m.QueryResult != nil {
+		return
+	}
+	if len(m.NavigationStack) == 0 {
+		m.WriteMessage("Nothing to go back to")
+		return
+	}
+
+	p := m.NavigationStack[len(m.NavigationStack)-1]
+	m.NavigationStack = m.NavigationStack[:len(m.NavigationStack)-1] // pop
+	if err := m.SetCursorPosition(p); err != nil {
+		m.WriteMessage(fmt.Sprintf("%v", err))
+	}
+}
@@ -0,0 +1,199 @@
+package viewer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mathaou/termdbms/tuiutil"
+)
+
+var queryCount int // gives every RunningQuery its own id
+
+// RunningQuery is a statement from :sql mode, a :find or an :import that runs in the background so the UI stays usable
+type RunningQuery struct {
+	ID        int
+	Statement string
+	Exec      bool       // update/delete/insert, reloads the tables when done instead of showing results
+	Find      string     // text :find searches every table for, instead of running Statement
+	Import    *CSVImport // csv file :import reads into a table, instead of running Statement
+	Started   time.Time
+	Cancel    context.CancelFunc // nil until the query has been dispatched
+	Cancelled bool
+	undoOld   string // what populateUndo returned, so a failed exec can be thrown away
+	undoNew   string
+}
+
+// QueryResultMsg is sent back to Update when a RunningQuery is done
+type QueryResultMsg struct {
+	ID       int
+	Columns  []string
+	Values   map[string][]interface{}
+	Affected int64
+	Err      error
+}
+
+// QueueQuery sets up a statement to run once Update gets control back, see StartQuery
+func QueueQuery(m *TuiModel, statement string, exec bool) {
+	queryCount++
+	m.Query = &RunningQuery{
+		ID:        queryCount,
+		Statement: statement,
+		Exec:      exec,
+	}
+	if exec {
+		m.Query.undoOld, m.Query.undoNew = populateUndo(m)
+	}
+}
+
+// StartQuery dispatches the queued query, it reports back with a QueryResultMsg
+func StartQuery(m *TuiModel) tea.Cmd {
+	q := m.Query
+	ctx, cancel := context.WithCancel(context.Background())
+	q.Cancel = cancel
+	q.Started = time.Now()
+	db := m.DefaultTable.Database.GetDatabaseReference()
+	id := q.ID
+	statement := q.Statement
+
+	if q.Find != "" {
+		d := m.DefaultTable.Database
+		var tables []string
+		for i := 1; i <= len(m.DefaultData.TableIndexMap); i++ {
+			tables = append(tables, m.DefaultData.TableIndexMap[i])
+		}
+		text := q.Find
+		find := func() tea.Msg {
+			hits, skipped, err := FindInTables(ctx, d, tables, text)
+			return FindResultMsg{ID: id, Text: text, Hits: hits, Skipped: skipped, Err: err}
+		}
+		return tea.Batch(find, spinner.Tick)
+	}
+
+	if q.Import != nil {
+		job := q.Import
+		options := job.Options
+		options.Progress = job.setProgress
+		run := func() tea.Msg {
+			rows, err := tuiutil.ImportCSV(ctx, db, job.FileName, job.TableName, options)
+			return ImportResultMsg{ID: id, Rows: rows, Err: err}
+		}
+		return tea.Batch(run, spinner.Tick)
+	}
+
+	run := func() tea.Msg {
+		if q.Exec {
+			res, err := db.ExecContext(ctx, statement)
+			if err != nil {
+				return QueryResultMsg{ID: id, Err: err}
+			}
+			affected, _ := res.RowsAffected()
+			return QueryResultMsg{ID: id, Affected: affected}
+		}
+
+		c, err := db.QueryContext(ctx, statement)
+		if err != nil {
+			return QueryResultMsg{ID: id, Err: err}
+		}
+		defer c.Close()
+
+		columns, values, err := ReadRows(c)
+		return QueryResultMsg{ID: id, Columns: columns, Values: values, Err: err}
+	}
+
+	return tea.Batch(run, spinner.Tick)
+}
+
+// CancelQuery stops the running query, the database gets interrupted and the result is dropped when it comes back
+func CancelQuery(m *TuiModel) {
+	if m.Query == nil || m.Query.Cancel == nil {
+		return
+	}
+
+	m.Query.Cancelled = true
+	m.Query.Cancel()
+}
+
+// finishQuery takes the running query off of the model once its result is in. Results of queries that were replaced
+// since are stale and give false
+func finishQuery(m *TuiModel, id int) (*RunningQuery, bool) {
+	q := m.Query
+	if q == nil || q.ID != id {
+		return nil, false
+	}
+	m.Query = nil
+	q.Cancel()
+
+	return q, true
+}
+
+// HandleQueryResult puts the results of a finished query on screen
+func HandleQueryResult(m *TuiModel, msg QueryResultMsg) {
+	q, ok := finishQuery(m, msg.ID)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(q.Started).Round(time.Millisecond)
+
+	if msg.Err != nil {
+		if q.Exec {
+			discardUndo(m, q.undoOld, q.undoNew)
+		}
+		if q.Cancelled {
+			m.WriteMessage(fmt.Sprintf("Cancelled query after %s", elapsed))
+			return
+		}
+		m.DisplayMessage(fmt.Sprintf("%v", msg.Err))
+		return
+	}
+
+	if q.Exec {
+		err := m.SetModel(nil, m.DefaultTable.Database.GetDatabaseReference())
+		if err != nil {
+			m.DisplayMessage(fmt.Sprintf("%v", err))
+			return
+		}
+		m.WriteMessage(fmt.Sprintf("%d row(s) affected in %s", msg.Affected, elapsed))
+		return
+	}
+
+	m.QueryResult = &TableState{
+		Database: m.DefaultTable.Database,
+		Data:     make(map[string]interface{}),
+	}
+	m.QueryData = &UIData{
+		TableHeaders:      make(map[string][]string),
+		TableIndexMap:     make(map[int]string),
+		TableSlices:       make(map[string][]interface{}),
+		TableHeadersSlice: []string{},
+		TableSorts:        make(map[string]ColumnSort),
+		TableFilters:      make(map[string]RowFilter),
+	}
+
+	i := 0
+	m.SetDataForResult(msg.Columns, msg.Values, &i, QueryResultsTableName)
+	m.UI.CurrentTable = 1
+	m.MouseData.Y = HeaderHeight
+	m.MouseData.X = 0
+	m.Scroll.ScrollXOffset = 0
+	m.WriteMessage(fmt.Sprintf("%d row(s) in %s", m.GetRowCount(), elapsed))
+}
+
+// GetQueryStatus is the spinner and running time shown in the footer while a query runs
+func GetQueryStatus(m *TuiModel) string {
+	if m.Query == nil || m.Query.Cancel == nil {
+		return ""
+	}
+
+	status := "running"
+	if m.Query.Cancelled {
+		status = "cancelling"
+	} else if m.Query.Import != nil {
+		rows, percent := m.Query.Import.GetProgress()
+		status = fmt.Sprintf("importing %d%% (%d rows)", percent, rows)
+	}
+
+	return fmt.Sprintf(" %s %s %s ", m.Spinner.View(), status, time.Since(m.Query.Started).Round(100*time.Millisecond))
+}
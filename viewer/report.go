@@ -0,0 +1,198 @@
+package viewer
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// GetReportString is a value the way it's shown in tables meant for reading, text cut down to options.Truncate characters
+func GetReportString(val interface{}, options ExportOptions) string {
+	s := GetExportString(val, options.Null)
+	if b, ok := val.([]byte); ok && !IsBlobText(b) {
+		s = GetBlobSummary(b)
+	}
+
+	if runes := []rune(s); options.Truncate > 0 && len(runes) > options.Truncate && !IsNumber(val) {
+		s = string(runes[:Max(options.Truncate-1, 0)]) + "…"
+	}
+
+	return s
+}
+
+// IsNumber is whether a value is a number, numbers get aligned to the right
+func IsNumber(val interface{}) bool {
+	switch val.(type) {
+	case int64, int32, int, float64, float32:
+		return true
+	}
+
+	return false
+}
+
+// MarkdownWriter writes rows as a GitHub flavored markdown table. Every row is kept until Flush, columns are only as
+// wide as what's in them and right aligned when they hold numbers
+type MarkdownWriter struct {
+	writer  io.Writer
+	options ExportOptions
+	headers []string
+	rows    [][]string
+	numbers []bool // whether every value of a column is a number
+	values  []bool // whether a column has any values at all
+}
+
+func NewMarkdownWriter(w io.Writer, options ExportOptions) RowWriter {
+	return &MarkdownWriter{
+		writer:  w,
+		options: options,
+	}
+}
+
+// escapeMarkdown keeps a value inside of its cell
+func escapeMarkdown(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+func (md *MarkdownWriter) WriteHeader(headers []string) error {
+	md.headers = make([]string, len(headers))
+	for i, h := range headers {
+		md.headers[i] = escapeMarkdown(h)
+	}
+	md.numbers = make([]bool, len(headers))
+	md.values = make([]bool, len(headers))
+
+	return nil
+}
+
+func (md *MarkdownWriter) WriteRow(row []interface{}) error {
+	cells := make([]string, len(md.headers))
+	for i := range cells {
+		if i >= len(row) {
+			continue
+		}
+		cells[i] = escapeMarkdown(GetReportString(row[i], md.options))
+		if row[i] != nil {
+			md.numbers[i] = IsNumber(row[i]) && (md.numbers[i] || !md.values[i])
+			md.values[i] = true
+		}
+	}
+	md.rows = append(md.rows, cells)
+
+	return nil
+}
+
+func (md *MarkdownWriter) Flush() error {
+	widths := make([]int, len(md.headers))
+	for i, h := range md.headers {
+		widths[i] = Max(lipgloss.Width(h), 3)
+		for _, row := range md.rows {
+			widths[i] = Max(widths[i], lipgloss.Width(row[i]))
+		}
+	}
+
+	pad := func(s string, i int) string {
+		padding := strings.Repeat(" ", widths[i]-lipgloss.Width(s))
+		if md.numbers[i] {
+			return padding + s
+		}
+		return s + padding
+	}
+	line := func(cells []string) string {
+		return "| " + strings.Join(cells, " | ") + " |\n"
+	}
+
+	var b strings.Builder
+	header := make([]string, len(md.headers))
+	separator := make([]string, len(md.headers))
+	for i, h := range md.headers {
+		header[i] = pad(h, i)
+		separator[i] = ":" + strings.Repeat("-", widths[i]-1)
+		if md.numbers[i] {
+			separator[i] = strings.Repeat("-", widths[i]-1) + ":"
+		}
+	}
+	b.WriteString(line(header))
+	b.WriteString(line(separator))
+	for _, row := range md.rows {
+		cells := make([]string, len(row))
+		for i, c := range row {
+			cells[i] = pad(c, i)
+		}
+		b.WriteString(line(cells))
+	}
+	md.rows = nil
+
+	_, err := io.WriteString(md.writer, b.String())
+	return err
+}
+
+// HTMLWriter writes rows as a table in a standalone HTML page. Numbers are right aligned and NULLs grayed out
+type HTMLWriter struct {
+	writer  io.Writer
+	options ExportOptions
+	started bool
+}
+
+func NewHTMLWriter(w io.Writer, options ExportOptions) RowWriter {
+	return &HTMLWriter{
+		writer:  w,
+		options: options,
+	}
+}
+
+func escapeHTML(s string) string {
+	return strings.ReplaceAll(html.EscapeString(s), "\n", "<br>")
+}
+
+func (h *HTMLWriter) WriteHeader(headers []string) error {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString(fmt.Sprintf("<title>%s</title>\n", escapeHTML(h.options.Title)))
+	b.WriteString("<style>\n")
+	b.WriteString("table { border-collapse: collapse; font-family: sans-serif; font-size: 14px; }\n")
+	b.WriteString("th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }\n")
+	b.WriteString("th { background: #f4f4f4; }\n")
+	b.WriteString("td.number { text-align: right; font-variant-numeric: tabular-nums; }\n")
+	b.WriteString("td.null { color: #999; }\n")
+	b.WriteString("</style>\n</head>\n<body>\n<table>\n<thead>\n<tr>")
+	for _, header := range headers {
+		b.WriteString("<th>" + escapeHTML(header) + "</th>")
+	}
+	b.WriteString("</tr>\n</thead>\n<tbody>\n")
+	h.started = true
+
+	_, err := io.WriteString(h.writer, b.String())
+	return err
+}
+
+func (h *HTMLWriter) WriteRow(row []interface{}) error {
+	var b strings.Builder
+	b.WriteString("<tr>")
+	for _, v := range row {
+		class := ""
+		if v == nil {
+			class = ` class="null"`
+		} else if IsNumber(v) {
+			class = ` class="number"`
+		}
+		b.WriteString(fmt.Sprintf("<td%s>%s</td>", class, escapeHTML(GetReportString(v, h.options))))
+	}
+	b.WriteString("</tr>\n")
+
+	_, err := io.WriteString(h.writer, b.String())
+	return err
+}
+
+func (h *HTMLWriter) Flush() error {
+	if !h.started {
+		return nil
+	}
+
+	_, err := io.WriteString(h.writer, "</tbody>\n</table>\n</body>\n</html>\n")
+	return err
+}
@@ -0,0 +1,121 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mathaou/termdbms/database"
+)
+
+// ShowSchema opens the schema of the current table in full screen view
+func ShowSchema(m *TuiModel) {
+	if m.QueryData != nil || m.QueryResult != nil {
+		m.WriteMessage("Query results don't have a schema, use :d to get back to the tables first")
+		return
+	}
+	schemaName := m.GetSchemaName()
+	if schemaName == "" {
+		return
+	}
+
+	schema, err := m.Table().Database.GetSchema(schemaName)
+	if err != nil {
+		m.DisplayMessage(fmt.Sprintf("%v", err))
+		return
+	}
+
+	m.Scroll.PreScrollYPosition = m.MouseData.Y
+	m.Scroll.PreScrollYOffset = m.Viewport.YOffset
+	m.Viewport.YOffset = 0
+	m.DisplayMessage(FormatSchema(schema))
+}
+
+// FormatSchema lays out a table schema as text, one section per kind of thing
+func FormatSchema(schema *database.TableSchema) string {
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "COLUMNS\n")
+	w := tabwriter.NewWriter(b, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "  name\ttype\tnull\tdefault\tkey\n")
+	for _, c := range schema.Columns {
+		null := "NULL"
+		if c.NotNull {
+			null = "NOT NULL"
+		}
+		def := ""
+		if c.Default.Valid {
+			def = c.Default.String
+		}
+		key := ""
+		if c.PrimaryKey > 0 {
+			key = fmt.Sprintf("PK %d", c.PrimaryKey)
+		}
+		for _, fk := range schema.ForeignKeys {
+			for i, column := range fk.Columns {
+				if column != c.Name {
+					continue
+				}
+				if key != "" {
+					key += ", "
+				}
+				key += "FK -> " + fk.Table
+				if i < len(fk.ReferencedColumns) {
+					key += "." + fk.ReferencedColumns[i]
+				}
+			}
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", c.Name, c.Type, null, def, key)
+	}
+	w.Flush()
+
+	fmt.Fprintf(b, "\nFOREIGN KEYS\n")
+	if len(schema.ForeignKeys) == 0 {
+		fmt.Fprintf(b, "  none\n")
+	}
+	for _, fk := range schema.ForeignKeys {
+		line := fmt.Sprintf("(%s) REFERENCES %s", strings.Join(fk.Columns, ", "), fk.Table)
+		if len(fk.ReferencedColumns) > 0 {
+			line += fmt.Sprintf("(%s)", strings.Join(fk.ReferencedColumns, ", "))
+		}
+		if fk.OnUpdate != "" {
+			line += " ON UPDATE " + fk.OnUpdate
+		}
+		if fk.OnDelete != "" {
+			line += " ON DELETE " + fk.OnDelete
+		}
+		if fk.Name != "" {
+			line = fk.Name + " " + line
+		}
+		fmt.Fprintf(b, "  %s\n", line)
+	}
+
+	fmt.Fprintf(b, "\nINDEXES\n")
+	if len(schema.Indexes) == 0 {
+		fmt.Fprintf(b, "  none\n")
+	}
+	for _, index := range schema.Indexes {
+		line := fmt.Sprintf("%s (%s)", index.Name, strings.Join(index.Columns, ", "))
+		if index.Primary {
+			line += " PRIMARY KEY"
+		} else if index.Unique {
+			line += " UNIQUE"
+		}
+		fmt.Fprintf(b, "  %s\n", line)
+		if index.SQL != "" {
+			fmt.Fprintf(b, "    %s\n", index.SQL)
+		}
+	}
+
+	fmt.Fprintf(b, "\nTRIGGERS\n")
+	if len(schema.Triggers) == 0 {
+		fmt.Fprintf(b, "  none\n")
+	}
+	for _, trigger := range schema.Triggers {
+		fmt.Fprintf(b, "  %s\n    %s\n", trigger.Name, strings.ReplaceAll(trigger.SQL, "\n", "\n    "))
+	}
+
+	fmt.Fprintf(b, "\nCREATE STATEMENT\n  %s\n", strings.ReplaceAll(schema.Create, "\n", "\n  "))
+
+	return b.String()
+}
@@ -14,11 +14,15 @@ import (
 
 var (
 	serializationErrorString = fmt.Sprintf("Database driver %s does not support serialization.", database.DriverString)
+	inMemoryErrorString      = "In-memory databases do not support serialization."
 )
 
 func Serialize(m *TuiModel) (string, error) {
 	switch m.Table().Database.(type) {
 	case *database.SQLite:
+		if exists, _ := Exists(m.Table().Database.GetFileName()); !exists {
+			return "", errors.New(inMemoryErrorString)
+		}
 		return SerializeSQLiteDB(m.Table().Database.(*database.SQLite), m), nil
 	default:
 		return "", errors.New(serializationErrorString)
@@ -29,6 +33,9 @@ func SerializeOverwrite(m *TuiModel) error {
 	t := m.Table()
 	switch t.Database.(type) {
 	case *database.SQLite:
+		if exists, _ := Exists(t.Database.GetFileName()); !exists {
+			return errors.New(inMemoryErrorString)
+		}
 		SerializeOverwriteSQLiteDB(t.Database.(*database.SQLite), m)
 		return nil
 	default:
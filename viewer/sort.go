@@ -0,0 +1,241 @@
+package viewer
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mathaou/termdbms/database"
+	"github.com/mathaou/termdbms/tuiutil"
+)
+
+// ColumnSort is the order a schema is shown in, Column is empty for its natural order
+type ColumnSort struct {
+	Column     string
+	Descending bool
+}
+
+// CycleSort moves the selected column through ascending, descending and back to unsorted
+func CycleSort(m *TuiModel) {
+	schemaName := m.GetSchemaName()
+	column := m.GetCursorColumnName()
+	if schemaName == "" || column == "" {
+		return
+	}
+
+	d := m.Data()
+	if d.TableSorts == nil {
+		d.TableSorts = make(map[string]ColumnSort)
+	}
+	s := d.TableSorts[schemaName]
+	if s.Column != column {
+		s.Column = column
+		s.Descending = false
+	} else if !s.Descending {
+		s.Descending = true
+	} else {
+		s.Column = ""
+		s.Descending = false
+	}
+
+	m.UI.SelectedRows = nil
+	if m.GetTableWindow() == nil { // query results are all in memory already
+		d.TableSorts[schemaName] = s
+		ArrangeInMemory(m, schemaName)
+		m.MoveCursorToRow(0)
+	} else {
+		// keep the cursor on the same row, wherever it ends up
+		key, _ := m.GetRowKeyAt(m.GetAbsoluteRow())
+		d.TableSorts[schemaName] = s
+		if err := m.LoadTable(m.Table().Database.GetDatabaseReference(), schemaName, m.UI.CurrentTable); err != nil {
+			m.DisplayMessage(fmt.Sprintf("%v", err))
+			return
+		}
+		row := 0
+		if key != nil {
+			row = Max(m.FindRow(key), 0)
+		}
+		m.MoveCursorToRow(row)
+	}
+
+	if s.Column == "" {
+		m.WriteMessage(fmt.Sprintf("Removed sorting from %s", schemaName))
+	} else if s.Descending {
+		m.WriteMessage(fmt.Sprintf("Sorted %s by %s, descending", schemaName, s.Column))
+	} else {
+		m.WriteMessage(fmt.Sprintf("Sorted %s by %s, ascending", schemaName, s.Column))
+	}
+}
+
+// ArrangeInMemory filters and sorts the rows of a schema that isn't paged in from the database, starting from the order they came back in
+func ArrangeInMemory(m *TuiModel, schemaName string) {
+	d := m.Data()
+	if d.OriginalData == nil {
+		d.OriginalData = make(map[string]map[string][]interface{})
+	}
+	original := d.OriginalData[schemaName]
+	if original == nil {
+		original = m.GetSchemaData()
+		d.OriginalData[schemaName] = original
+	}
+
+	filter := d.TableFilters[schemaName]
+	headers := d.TableHeaders[schemaName]
+	total := 0
+	for _, v := range original {
+		total = Max(total, len(v))
+	}
+
+	var order []int
+	for i := 0; i < total; i++ {
+		if filter.MatchesRow(original, headers, i) {
+			order = append(order, i)
+		}
+	}
+
+	if s := d.TableSorts[schemaName]; s.Column != "" {
+		values := original[s.Column]
+		sort.SliceStable(order, func(i, j int) bool {
+			c := CompareValues(values[order[i]], values[order[j]])
+			if s.Descending {
+				return c > 0
+			}
+			return c < 0
+		})
+	}
+
+	arranged := make(map[string][]interface{})
+	for column, v := range original {
+		buffer := make([]interface{}, len(order))
+		for i, o := range order {
+			if o < len(v) {
+				buffer[i] = v[o]
+			}
+		}
+		arranged[column] = buffer
+	}
+	m.Table().Data[schemaName] = arranged
+}
+
+// CompareValues orders cell values the way sqlite does, NULL then numbers then text then blobs
+func CompareValues(a, b interface{}) int {
+	rank := func(v interface{}) int {
+		switch v.(type) {
+		case nil:
+			return 0
+		case int64, float64, bool:
+			return 1
+		case time.Time:
+			return 2
+		case string:
+			return 3
+		default:
+			return 4
+		}
+	}
+	number := func(v interface{}) float64 {
+		switch n := v.(type) {
+		case int64:
+			return float64(n)
+		case float64:
+			return n
+		case bool:
+			if n {
+				return 1
+			}
+		}
+		return 0
+	}
+
+	ra, rb := rank(a), rank(b)
+	if ra != rb {
+		return ra - rb
+	}
+
+	switch ra {
+	case 1:
+		x, xInt := a.(int64)
+		y, yInt := b.(int64)
+		if !xInt || !yInt { // only go through floats when they have to, big integers lose precision
+			return compareFloats(number(a), number(b))
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case 2:
+		x, y := a.(time.Time), b.(time.Time)
+		switch {
+		case x.Before(y):
+			return -1
+		case x.After(y):
+			return 1
+		}
+		return 0
+	case 3:
+		return strings.Compare(a.(string), b.(string))
+	case 4:
+		x, _ := a.([]byte)
+		y, _ := b.([]byte)
+		return bytes.Compare(x, y)
+	}
+
+	return 0
+}
+
+func compareFloats(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// GetOrderBy orders rows by the sorted column, then by their row identity so that pagination is stable. Empty when there's neither
+func GetOrderBy(d database.Database, identity *database.RowIdentity, s ColumnSort) string {
+	var columns []string
+	if s.Column != "" {
+		direction := "asc"
+		if s.Descending {
+			direction = "desc"
+		}
+		columns = append(columns, fmt.Sprintf("%s %s", d.QuoteIdentifier(s.Column), direction))
+	}
+	if identity != nil {
+		for _, k := range identity.Columns {
+			columns = append(columns, GetKeyColumnExpression(d, identity, k))
+		}
+	}
+
+	if len(columns) == 0 {
+		return ""
+	}
+
+	return " order by " + strings.Join(columns, ", ")
+}
+
+// GetSortIndicator is shown in front of a column header when the schema is sorted by it
+func GetSortIndicator(m *TuiModel, column string) string {
+	s := m.Data().TableSorts[m.GetSchemaName()]
+	if s.Column != column {
+		return ""
+	}
+
+	if tuiutil.Ascii {
+		if s.Descending {
+			return "v "
+		}
+		return "^ "
+	}
+	if s.Descending {
+		return "▼ "
+	}
+	return "▲ "
+}
@@ -5,7 +5,6 @@ import (
 	"strings"
 	"time"
 
-	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/mathaou/termdbms/database"
 	"github.com/mathaou/termdbms/tuiutil"
@@ -26,7 +25,7 @@ func init() {
 	MIP = false
 	mid = &tmp
 	HeaderAssembly = func(m *TuiModel, s *string, done *chan bool) {
-		if m.UI.ShowClipboard {
+		if m.UI.ShowClipboard || m.UI.ShowFind {
 			*done <- true
 			return
 		}
@@ -49,7 +48,7 @@ func init() {
 				continue
 			}
 
-			text := " " + TruncateIfApplicable(m, d)
+			text := " " + GetSortIndicator(m, d) + TruncateIfApplicable(m, d)
 			builder = append(builder, style.
 				Render(text))
 		}
@@ -58,18 +57,27 @@ func init() {
 			// schema name
 			var headerTop string
 
-			if m.UI.EditModeEnabled || m.UI.FormatModeEnabled {
+			if m.Confirm != nil {
+				headerTop = HeaderStyle.Copy().Bold(true).Render(m.Confirm.Prompt)
+			} else if m.UI.EditModeEnabled || m.UI.FormatModeEnabled {
 				headerTop = m.TextInput.Model.View()
 				if !m.TextInput.Model.Focused() {
 					headerTop = HeaderStyle.Copy().Faint(true).Render(headerTop)
 				}
 			} else {
-				headerTop = fmt.Sprintf(" %s (%d/%d) - %d record(s) + %d column(s)",
+				headerTop = fmt.Sprintf(" %s%s (%d/%d) - %d record(s) + %d column(s)",
 					m.GetSchemaName(),
+					GetKindLabel(m.Data().TableKinds[m.GetSchemaName()], m.IsAttachedTable(m.GetSchemaName())),
 					m.UI.CurrentTable,
-					len(m.Data().TableHeaders), // look at how headers get rendered to get accurate record number
-					len(m.GetColumnData()),
-					len(m.GetHeaders())) // this will need to be refactored when filters get added
+					len(m.Data().TableIndexMap), // look at how headers get rendered to get accurate record number
+					m.GetRowCount(),
+					len(m.GetHeaders()))
+				if filter := m.Data().TableFilters[m.GetSchemaName()]; filter.Active() {
+					headerTop += fmt.Sprintf(" - filtered by /%s", filter)
+				}
+				if selected := len(m.UI.SelectedRows); selected > 0 {
+					headerTop += fmt.Sprintf(" - %d selected", selected)
+				}
 				headerTop = HeaderStyle.Render(headerTop)
 			}
 
@@ -83,7 +91,7 @@ func init() {
 		*done <- true
 	}
 	FooterAssembly = func(m *TuiModel, s *string, done *chan bool) {
-		if m.UI.ShowClipboard {
+		if m.UI.ShowClipboard || m.UI.ShowFind {
 			*done <- true
 			return
 		}
@@ -110,6 +118,7 @@ func init() {
 			undoRedoInfo = ""
 			break
 		}
+		undoRedoInfo = GetQueryStatus(m) + undoRedoInfo
 
 		gapSize := m.Viewport.Width - lipgloss.Width(footer) - lipgloss.Width(undoRedoInfo) - 2
 
@@ -130,7 +139,7 @@ func init() {
 				*mid = half + Message + half
 				time.Sleep(time.Second * 5)
 				Message = ""
-				go Program.Send(tea.KeyMsg{})
+				go Program.Send(RedrawMsg{})
 			}()
 		} else if Message == "" {
 			*mid = strings.Repeat("-", gapSize)
@@ -2,8 +2,11 @@ package viewer
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mathaou/termdbms/database"
 	"github.com/mathaou/termdbms/tuiutil"
 )
 
@@ -14,6 +17,9 @@ func AssembleTable(m *TuiModel) string {
 	if m.UI.ShowClipboard {
 		return ShowClipboard(m)
 	}
+	if m.UI.ShowFind {
+		return m.FindList.View()
+	}
 	if m.UI.RenderSelection {
 		return DisplaySelection(m)
 	}
@@ -140,10 +146,13 @@ func (m *TuiModel) SetViewSlices() {
 			}
 		}()
 
+		m.LoadRowsInView()
+		offset := m.GetWindowOffset() // loaded rows don't necessarily start at the top of the table
+
 		for _, columnName := range headers {
 			interfaceValues := m.GetSchemaData()[columnName]
 			if len(interfaceValues) >= m.Viewport.Height {
-				min := Min(m.Viewport.YOffset, len(interfaceValues)-m.Viewport.Height)
+				min := Max(Min(m.Viewport.YOffset-offset, len(interfaceValues)-m.Viewport.Height), 0)
 
 				d.TableSlices[columnName] = interfaceValues[min : m.Viewport.Height+min]
 			} else {
@@ -171,12 +180,41 @@ func (m *TuiModel) GetSelectedColumnName() string {
 	col := m.GetColumn()
 	headers := m.GetHeaders()
 	index := Min(m.NumHeaders()-1, col)
+	if m.UI.ExpandColumn == -1 { // scrolled sideways the column can be past the number shown
+		index = Min(len(headers)-1, col)
+	}
 	if len(headers) == 0 {
 		return ""
 	}
 	return headers[index]
 }
 
+// GetSelectedColumnType gets the declared type of the selected column, empty if it doesn't have one
+func (m *TuiModel) GetSelectedColumnType() string {
+	if m.QueryData != nil {
+		return ""
+	}
+
+	columns, _ := m.Table().Database.GetColumns(m.GetSchemaName())
+	name := m.GetSelectedColumnName()
+	for _, c := range columns {
+		if c.Name == name {
+			return c.Type
+		}
+	}
+
+	return ""
+}
+
+// GetCursorColumnName gets the name of the column under the cursor, taking horizontal scrolling into account
+func (m *TuiModel) GetCursorColumnName() string {
+	if visible := m.Data().TableHeadersSlice; m.GetColumn() < len(visible) {
+		return visible[m.GetColumn()]
+	}
+
+	return m.GetSelectedColumnName()
+}
+
 func (m *TuiModel) GetColumnData() []interface{} {
 	schemaData := m.GetSchemaData()
 	if schemaData == nil {
@@ -185,6 +223,29 @@ func (m *TuiModel) GetColumnData() []interface{} {
 	return schemaData[m.GetSelectedColumnName()]
 }
 
+// GetTableWindow gets which rows of the current schema are loaded, nil for query results which are always fully loaded
+func (m *TuiModel) GetTableWindow() *TableWindow {
+	return m.Data().TableWindows[m.GetSchemaName()]
+}
+
+// GetWindowOffset gets the row index within the whole table of the first row in GetSchemaData
+func (m *TuiModel) GetWindowOffset() int {
+	if window := m.GetTableWindow(); window != nil {
+		return window.Offset
+	}
+
+	return 0
+}
+
+// GetRowCount gets the number of rows in the current schema, loaded or not
+func (m *TuiModel) GetRowCount() int {
+	if window := m.GetTableWindow(); window != nil && window.Loaded {
+		return window.Total
+	}
+
+	return len(m.GetColumnData())
+}
+
 func (m *TuiModel) GetRowData() map[string]interface{} {
 	defer func() {
 		if recover() != nil {
@@ -193,14 +254,174 @@ func (m *TuiModel) GetRowData() map[string]interface{} {
 	}()
 	headers := m.GetHeaders()
 	schema := m.GetSchemaData()
+	row := m.GetAbsoluteRow() - m.GetWindowOffset()
 	data := make(map[string]interface{})
 	for _, v := range headers {
-		data[v] = schema[v][m.GetRow()]
+		data[v] = schema[v][row]
 	}
 
 	return data
 }
 
+// GetAbsoluteRow gets the row under the cursor within all of the schema's data, regardless of mode
+func (m *TuiModel) GetAbsoluteRow() int {
+	if m.UI.RenderSelection || m.UI.EditModeEnabled || m.UI.FormatModeEnabled {
+		return m.GetRow()
+	}
+
+	return m.Viewport.YOffset + m.GetRow()
+}
+
+// CheckEditable fails for schemas whose rows can't be changed, like views and attached tables
+func (m *TuiModel) CheckEditable(schemaName string) error {
+	if m.Data().TableKinds[schemaName] == database.TableKindView {
+		return fmt.Errorf("%s %w", schemaName, database.ErrReadOnly)
+	}
+	if m.IsAttachedTable(schemaName) {
+		return fmt.Errorf("%s %w", schemaName, database.ErrAttached)
+	}
+
+	return nil
+}
+
+// IsAttachedTable is whether a schema is in a database added with :attach
+func (m *TuiModel) IsAttachedTable(schemaName string) bool {
+	s, ok := m.Table().Database.(*database.SQLite)
+	return ok && s.IsAttachedTable(schemaName)
+}
+
+// GetRowKey gets the row identity values (primary key or rowid) of the selected row
+func (m *TuiModel) GetRowKey() (map[string]interface{}, error) {
+	return m.GetRowKeyAt(m.GetAbsoluteRow())
+}
+
+// GetRowKeyAt gets the row identity values of any row in the current schema
+func (m *TuiModel) GetRowKeyAt(row int) (map[string]interface{}, error) {
+	schemaName := m.GetSchemaName()
+	if err := m.CheckEditable(schemaName); err != nil {
+		return nil, err
+	}
+	identity := m.Data().TableKeys[schemaName]
+	if identity == nil {
+		if _, err := m.Table().Database.GetRowIdentity(schemaName); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("table %s %w", schemaName, database.ErrNoRowIdentity)
+	}
+
+	schema := m.GetSchemaData()
+	i := row - m.GetWindowOffset()
+	key := make(map[string]interface{})
+	for _, k := range identity.Columns {
+		if i < 0 || i >= len(schema[k]) {
+			return nil, fmt.Errorf("row %d is out of range", row)
+		}
+		key[k] = schema[k][i]
+	}
+
+	return key, nil
+}
+
+// FindRow gets the index of the row with the given row identity values in the current schema, -1 if it isn't there
+func (m *TuiModel) FindRow(key map[string]interface{}) int {
+	schema := m.GetSchemaData()
+	offset := m.GetWindowOffset()
+	for row := range schema[m.GetSelectedColumnName()] {
+		match := true
+		for k, v := range key {
+			if row >= len(schema[k]) || fmt.Sprint(schema[k][row]) != fmt.Sprint(v) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return offset + row
+		}
+	}
+
+	if m.GetTableWindow() == nil {
+		return -1
+	}
+
+	// not loaded, so ask the database where it is
+	row, err := m.GetRowPosition(key)
+	if err != nil {
+		return -1
+	}
+
+	return row
+}
+
+// GetRowPosition asks the database for the index of the row with the given row identity values, in the order tables are paged in
+func (m *TuiModel) GetRowPosition(key map[string]interface{}) (int, error) {
+	schemaName := m.GetSchemaName()
+	identity := m.Data().TableKeys[schemaName]
+	if identity == nil {
+		return -1, fmt.Errorf("table %s %w", schemaName, database.ErrNoRowIdentity)
+	}
+
+	d := m.Table().Database
+	filter, values := m.GetTableCondition(schemaName, 1) // numbering has to match the filtered rows
+	var (
+		columns []string
+		where   []string
+	)
+	for i, k := range identity.Columns {
+		columns = append(columns, fmt.Sprintf("%s as %s", GetKeyColumnExpression(d, identity, k), d.QuoteIdentifier(k)))
+		where = append(where, fmt.Sprintf("%s = %s", d.QuoteIdentifier(k), d.GetPlaceholderForDatabaseType(len(values)+i+1)))
+		values = append(values, key[k])
+	}
+
+	query := fmt.Sprintf("select n from (select row_number() over (%s) as n, %s from %s%s) as numbered where %s",
+		strings.TrimSpace(GetOrderBy(d, identity, m.Data().TableSorts[schemaName])),
+		strings.Join(columns, ", "),
+		d.QuoteTableName(schemaName),
+		filter,
+		strings.Join(where, " and "))
+
+	row := 0
+	if err := d.GetDatabaseReference().QueryRow(query, values...).Scan(&row); err != nil {
+		return -1, err
+	}
+
+	return row - 1, nil // row_number starts at 1
+}
+
+// MoveCursorToRow scrolls the viewport so that row is visible and puts the cursor on it
+func (m *TuiModel) MoveCursorToRow(row int) {
+	total := m.GetRowCount()
+	offset := 0
+	if total > m.Viewport.Height {
+		offset = Min(row, total-m.Viewport.Height)
+	}
+
+	m.Viewport.YOffset = offset
+	m.MouseData.Y = HeaderHeight + row - offset
+	m.Scroll.PreScrollYOffset = m.Viewport.YOffset
+	m.Scroll.PreScrollYPosition = m.MouseData.Y
+}
+
+// MoveCursorToColumn scrolls sideways so that the column is visible and puts the cursor on it
+func (m *TuiModel) MoveCursorToColumn(column string) {
+	headers := m.GetHeaders()
+	index := -1
+	for i, h := range headers {
+		if h == column {
+			index = i
+		}
+	}
+	if index < 0 {
+		return
+	}
+
+	visible := m.NumHeaders() // also sets maxHeaders
+	m.Scroll.ScrollXOffset = 0
+	if len(headers) > maxHeaders && index >= visible {
+		m.Scroll.ScrollXOffset = Min(index-(visible-1), len(headers)-maxHeaders+1)
+	}
+	m.MouseData.X = (index - m.Scroll.ScrollXOffset) * m.CellWidth()
+}
+
 func (m *TuiModel) GetSelectedOption() (*interface{}, int, []interface{}) {
 	if !m.UI.FormatModeEnabled {
 		m.Scroll.PreScrollYOffset = m.Viewport.YOffset
@@ -208,14 +429,16 @@ func (m *TuiModel) GetSelectedOption() (*interface{}, int, []interface{}) {
 	}
 	row := m.GetRow()
 	col := m.GetColumnData()
-	if row >= len(col) {
+	i := row - m.GetWindowOffset()
+	if i < 0 || i >= len(col) {
 		return nil, row, col
 	}
-	return &col[row], row, col
+	return &col[i], row, col
 }
 
 func (m *TuiModel) DisplayMessage(msg string) {
 	m.Data().EditTextBuffer = msg
+	m.UI.SelectedBlob = nil
 	m.UI.EditModeEnabled = false
 	m.UI.RenderSelection = true
 }
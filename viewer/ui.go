@@ -29,19 +29,23 @@ func SelectOption(m *TuiModel) {
 	}
 
 	m.UI.RenderSelection = true
-	raw, _, col := m.GetSelectedOption()
+	raw, _, _ := m.GetSelectedOption()
 	if raw == nil {
 		return
 	}
-	l := len(col)
+	l := m.GetRowCount()
 	row := m.Viewport.YOffset + m.MouseData.Y - HeaderHeight
 
 	if row <= l && l > 0 &&
 		m.MouseData.Y >= HeaderHeight &&
 		m.MouseData.Y < m.Viewport.Height+HeaderHeight &&
 		m.MouseData.X < m.CellWidth()*(len(m.Data().TableHeadersSlice)) {
+		m.UI.SelectedBlob = nil
 		if conv, ok := (*raw).(string); ok {
 			m.Data().EditTextBuffer = conv
+		} else if b, ok := (*raw).([]byte); ok {
+			m.UI.SelectedBlob = b
+			m.Data().EditTextBuffer = GetBlobView(b)
 		} else {
 			m.Data().EditTextBuffer = ""
 		}
@@ -117,6 +121,13 @@ func DisplayTable(m *TuiModel) string {
 			s := GetStringRepresentationOfInterface(val)
 			s = " " + s
 			// handle highlighting
+			if _, selected := m.UI.SelectedRows[m.Viewport.YOffset+r]; selected {
+				if !tuiutil.Ascii {
+					base = base.Reverse(true)
+				} else {
+					s = "*" + s
+				}
+			}
 			if c == m.GetColumn() && r == m.GetRow() {
 				if !tuiutil.Ascii {
 					base.Foreground(lipgloss.Color(tuiutil.Highlight()))
@@ -256,6 +267,10 @@ func DisplaySelection(m *TuiModel) string {
 	}
 
 	var prettyPrint string
+	row -= m.GetWindowOffset()
+	if row < 0 || row >= len(col) {
+		return DisplayTable(m)
+	}
 	raw := col[row]
 
 	if conv, ok := raw.(int64); ok {
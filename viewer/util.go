@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mathaou/termdbms/database"
 )
 
 const (
@@ -50,31 +51,27 @@ func TruncateIfApplicable(m *TuiModel, conv string) (s string) {
 	return s
 }
 
-func GetInterfaceFromString(str string, original *interface{}) interface{} {
+// GetInterfaceFromString parses edited text into a value for a column, going by its declared type when it has one
+// and by the type of what was in the cell when it doesn't
+func GetInterfaceFromString(d database.Database, declared, str string, original *interface{}) (interface{}, error) {
+	if database.GetColumnKind(declared) != database.KindAny || original == nil {
+		return database.ParseValue(d, declared, str)
+	}
+
 	switch (*original).(type) {
 	case bool:
-		bVal, _ := strconv.ParseBool(str)
-		return bVal
-	case int64:
-		iVal, _ := strconv.ParseInt(str, 10, 64)
-		return iVal
-	case int32:
-		iVal, _ := strconv.ParseInt(str, 10, 64)
-		return iVal
-	case float64:
-		fVal, _ := strconv.ParseFloat(str, 64)
-		return fVal
-	case float32:
-		fVal, _ := strconv.ParseFloat(str, 64)
-		return fVal
+		return database.ParseValue(d, "boolean", str)
+	case int64, int32:
+		return database.ParseValue(d, "integer", str)
+	case float64, float32:
+		return database.ParseValue(d, "real", str)
 	case time.Time:
-		t := (*original).(time.Time)
-		return t // TODO figure out how to handle things like time and date
-	case string:
-		return str
+		return database.ParseValue(d, "datetime", str)
+	case []byte:
+		return database.ParseValue(d, "blob", str)
 	}
 
-	return nil
+	return str, nil
 }
 
 func GetStringRepresentationOfInterface(val interface{}) string {
@@ -91,6 +88,10 @@ func GetStringRepresentationOfInterface(val interface{}) string {
 	} else if t, ok := val.(time.Time); ok {
 		str := t.String()
 		return str
+	} else if b, ok := val.(bool); ok { // postgres hands these back as actual booleans
+		return strconv.FormatBool(b)
+	} else if b, ok := val.([]byte); ok {
+		return GetBlobSummary(b)
 	} else if val == nil {
 		return "NULL"
 	}
@@ -98,47 +99,41 @@ func GetStringRepresentationOfInterface(val interface{}) string {
 	return ""
 }
 
-func WriteCSV(m *TuiModel) { // basically display table but without any styling
-	if m.QueryData == nil || m.QueryResult == nil {
-		return // should never happen but just making sure
-	}
-
-	var (
-		builder [][]string
-		buffer  strings.Builder
-	)
-
-	d := m.Data()
-
-	// go through all columns
-	for _, columnName := range d.TableHeaders[QueryResultsTableName] {
-		var (
-			rowBuilder []string
-		)
-
-		columnValues := m.GetSchemaData()[columnName]
-		rowBuilder = append(rowBuilder, columnName)
-		for _, val := range columnValues {
-			s := GetStringRepresentationOfInterface(val)
-			// display text based on type
-			rowBuilder = append(rowBuilder, s)
-		}
-		builder = append(builder, rowBuilder)
+// GetEditableStringOfInterface is a value the way it is put in the editor. Nothing is lost, so saving it unchanged gives
+// the same value back: floats keep every digit, BLOBs are x'hex' and NULL is left empty rather than shown as "NULL".
+// Whether an empty editor means NULL or an empty string is up to IsUnchanged
+func GetEditableStringOfInterface(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return GetBlobLiteral(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
 	}
 
-	depth := len(builder[0])
-	headers := len(builder)
+	return GetStringRepresentationOfInterface(val)
+}
 
-	for i := 0; i < depth; i++ {
-		var r []string
-		for x := 0; x < headers; x++ {
-			r = append(r, builder[x][i])
-		}
-		buffer.WriteString(strings.Join(r, ","))
-		buffer.WriteString("\n")
+// IsUnchanged is whether what was typed is the value the editor started with, in which case nothing gets saved.
+// Untouched cells never change. NULL is compared as a value, so a NULL cell edited down to nothing becomes an empty string
+func IsUnchanged(original interface{}, input string, touched bool) bool {
+	if !touched {
+		return true
 	}
-
-	WriteTextFile(m, buffer.String())
+	if original == nil {
+		return false
+	}
+	str := GetEditableStringOfInterface(original)
+	if input == str {
+		return true
+	}
+	conv, err := FormatJson(str) // format mode prettifies json
+	return err == nil && input == conv
 }
 
 func WriteTextFile(m *TuiModel, text string) (string, error) {
@@ -200,7 +195,7 @@ func GetScrollDownMaximumForSelection(m *TuiModel) int {
 	} else if m.UI.FormatModeEnabled {
 		max = len(SplitLines(DisplayFormatText(m)))
 	} else {
-		return len(m.GetColumnData())
+		return m.GetRowCount()
 	}
 
 	return max
@@ -3,6 +3,7 @@ package viewer
 import (
 	"fmt"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/mathaou/termdbms/list"
@@ -75,7 +76,32 @@ func (m TuiModel) Update(message tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	switch msg := message.(type) {
+	case QueryResultMsg:
+		HandleQueryResult(&m, msg)
+		m.SetViewSlices()
+		break
+	case FindResultMsg:
+		HandleFindResult(&m, msg)
+		m.SetViewSlices()
+		break
+	case ImportResultMsg:
+		HandleImportResult(&m, msg)
+		m.SetViewSlices()
+		break
+	case RedrawMsg: // nothing to do, the screen is drawn after every message
+		break
+	case spinner.TickMsg:
+		if m.Query != nil { // stops ticking once the query is done
+			var tick tea.Cmd
+			m.Spinner, tick = m.Spinner.Update(msg)
+			commands = append(commands, tick)
+		}
+		break
 	case list.FilterMatchesMessage:
+		if m.UI.ShowFind {
+			m.FindList, command = m.FindList.Update(msg)
+			break
+		}
 		m.ClipboardList, command = m.ClipboardList.Update(msg)
 		break
 	case tea.MouseMsg:
@@ -94,6 +120,11 @@ func (m TuiModel) Update(message tea.Msg) (tea.Model, tea.Cmd) {
 			HandleClipboardEvents(&m, str, &command, msg)
 			break
 		}
+		if m.UI.ShowFind {
+			HandleFindEvents(&m, str, &command, msg)
+			m.SetViewSlices()
+			break
+		}
 
 		// when fullscreen selection viewing is in session, don't allow UI manipulation other than quit or exit
 		s := msg.String()
@@ -108,6 +139,22 @@ func (m TuiModel) Update(message tea.Msg) (tea.Model, tea.Cmd) {
 			break
 		}
 
+		if m.Query != nil { // only cancelling is allowed while a query runs
+			if s == "ctrl+c" && m.Query.Cancelled { // the database isn't listening, give up
+				return m, tea.Quit
+			}
+			if s == "ctrl+c" || s == "esc" {
+				CancelQuery(&m)
+			}
+			break
+		}
+
+		if m.Confirm != nil && s != "ctrl+c" {
+			HandleConfirmation(&m, s)
+			m.SetViewSlices()
+			break
+		}
+
 		if s == "ctrl+c" || (s == "q" && (!m.UI.EditModeEnabled && !m.UI.FormatModeEnabled)) {
 			return m, tea.Quit
 		}
@@ -116,6 +163,9 @@ func (m TuiModel) Update(message tea.Msg) (tea.Model, tea.Cmd) {
 		if event != nil {
 			commands = append(commands, event)
 		}
+		if m.Query != nil && m.Query.Cancel == nil { // a query was just queued
+			commands = append(commands, StartQuery(&m))
+		}
 		if !m.UI.EditModeEnabled && m.Ready {
 			m.SetViewSlices()
 			if m.UI.FormatModeEnabled {
@@ -153,7 +203,7 @@ func (m TuiModel) View() string {
 		done <- true
 	}(&content)
 
-	if m.UI.ShowClipboard {
+	if m.UI.ShowClipboard || m.UI.ShowFind {
 		<-done
 		return content
 	}